@@ -7,12 +7,15 @@
 package mpd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/textproto"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -52,14 +55,56 @@ func quoteArgs(args []string) string {
 }
 
 // Client represents a client connection to a MPD server.
+//
+// A Client is safe for concurrent use by multiple goroutines. Requests are
+// serialized: each one waits for the previous request to be sent and its
+// response to be fully read before it is sent to MPD.
+//
+// A Client returned by WithContext shares the connection with the Client it
+// was derived from, but uses its own context for requests. Likewise, the
+// Client of a PartitionClient shares the connection, but its requests are
+// run in a given partition.
 type Client struct {
+	*clientConn
+	ctx         context.Context
+	inPartition string // partition selected before each request, if any
+}
+
+// clientConn is the connection state shared by a Client and all the
+// Clients derived from it.
+type clientConn struct {
+	sem     chan struct{} // held during a request/response exchange
 	text    *textproto.Conn
 	version string
+
+	mu      sync.Mutex // guards netConn, version, closed and changes of text
+	netConn net.Conn
+	closed  bool // Close was called
+
+	// Used to reconnect.
+	network     string
+	addr        string
+	dialOptions dialOptions
+	partition   string   // partition chosen by Partition
+	selected    string   // partition selected on the connection
+	tagTypes    []string // tagtypes commands to replay
+
+	// inResponse is set when a command is sent, and cleared once the end
+	// of its response (OK or ACK) has been read.
+	inResponse bool
 }
 
+// ErrClosed is returned when a request is made on a closed connection.
+var ErrClosed = errors.New("connection closed")
+
+// aLongTimeAgo is a non-zero time in the past, used to unblock pending
+// reads and writes on the connection.
+var aLongTimeAgo = time.Unix(1, 0)
+
 // Error represents an error returned by the MPD server.
 // It contains the error number, the index of the causing command in the command list,
 // the name of the command in the command list and the error message.
+// errors.Is matches an Error with the error of its code, e.g. ErrNoExist.
 type Error struct {
 	Code             ErrorCode
 	CommandListIndex int
@@ -100,23 +145,15 @@ type Attrs map[string]string
 // Dial connects to MPD listening on address addr (e.g. "127.0.0.1:6600")
 // on network network (e.g. "tcp").
 func Dial(network, addr string) (c *Client, err error) {
-	text, err := textproto.Dial(network, addr)
-	if err != nil {
-		return nil, err
-	}
-	line, err := text.ReadLine()
-	if err != nil {
-		return nil, err
-	}
-	if line[0:6] != "OK MPD" {
-		return nil, textproto.ProtocolError("no greeting")
-	}
-	return &Client{text: text, version: line[7:]}, nil
+	return DialWithOptions(network, addr)
 }
 
 // DialAuthenticated connects to MPD listening on address addr (e.g. "127.0.0.1:6600")
 // on network network (e.g. "tcp"). It then authenticates with MPD
-// using the plaintext password password if it's not empty.
+// using the plaintext password password if it's not empty. If
+// authentication fails, the error is returned along with the connected
+// client, which must then be closed. Use DialWithOptions and WithPassword
+// to only get a client if authentication succeeds.
 func DialAuthenticated(network, addr, password string) (c *Client, err error) {
 	c, err = Dial(network, addr)
 	if err == nil && len(password) > 0 {
@@ -127,44 +164,297 @@ func DialAuthenticated(network, addr, password string) (c *Client, err error) {
 
 // Version returns the protocol version used as provided during the handshake.
 func (c *Client) Version() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.version
 }
 
+// Context returns the client's context. The returned context is always
+// non-nil; it defaults to the background context.
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+// WithContext returns a shallow copy of c with its context changed to ctx.
+// The returned Client shares the connection with c, but all of its requests
+// are bound to ctx: if ctx is done before a response has been read, the
+// request fails with ctx.Err() and the connection is closed, because it can
+// no longer be used to send further requests.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// begin prepares the connection for a request/response exchange. The
+// returned function must be called with the result of the exchange once it
+// is over; it returns the error that should be reported to the caller.
+func (c *Client) begin() (end func(error) error, err error) {
+	ctx := c.Context()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	broken := c.text == nil
+	finish, err := c.startInPartition(ctx)
+	if err != nil && !broken && c.dialOptions.reconnectAttempts > 0 && isConnError(err) {
+		// The existing connection turned out to be broken while selecting
+		// the partition. The request has not been sent yet, so it can be
+		// sent on a new connection. A failed reconnection is not retried.
+		finish, err = c.startInPartition(ctx)
+	}
+	if err != nil {
+		<-c.sem
+		return nil, err
+	}
+	return func(err error) error {
+		defer func() { <-c.sem }()
+		return finish(c.resync(err))
+	}, nil
+}
+
+// startInPartition starts an exchange like start, and selects the
+// partition of c. It must be called with c.sem held.
+func (c *Client) startInPartition(ctx context.Context) (end func(error) error, err error) {
+	end, err = c.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.selectPartition(); err != nil {
+		return nil, end(c.resync(err))
+	}
+	return end, nil
+}
+
+// selectPartition switches the connection to the partition of c, unless it
+// is already selected: c.inPartition, or else the partition chosen by
+// Partition. It must be called at the start of an exchange.
+func (c *Client) selectPartition() error {
+	name := c.inPartition
+	if name == "" {
+		name = partitionName(c.partition)
+	}
+	if name == partitionName(c.selected) {
+		return nil
+	}
+	id, err := c.cmd("partition %s", quote(name))
+	if err != nil {
+		return err
+	}
+	c.text.StartResponse(id)
+	defer c.text.EndResponse(id)
+	if err := c.readOKLine("OK"); err != nil {
+		return err
+	}
+	c.selected = name
+	return nil
+}
+
+// start starts an exchange on the connection. It must be called with
+// c.sem held.
+func (c *Client) start(ctx context.Context) (end func(error) error, err error) {
+	if c.isClosed() {
+		// The connection may have been closed by Close while another
+		// request was in progress.
+		c.abort()
+		return nil, ErrClosed
+	}
+	if c.text == nil {
+		if c.dialOptions.reconnectAttempts <= 0 {
+			return nil, ErrClosed
+		}
+		if err := c.reconnect(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if ctx.Done() == nil {
+		return c.done, nil
+	}
+	stop := make(chan struct{})
+	interrupted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Unblock any pending read or write.
+			c.netConn.SetDeadline(aLongTimeAgo)
+			interrupted <- true
+		case <-stop:
+			interrupted <- false
+		}
+	}()
+	return func(err error) error {
+		close(stop)
+		if !<-interrupted {
+			return c.done(err)
+		}
+		if err == nil {
+			// The exchange completed before the deadline took effect.
+			c.netConn.SetDeadline(time.Time{})
+			return nil
+		}
+		// We may be in the middle of a response, so the connection
+		// can't be trusted anymore.
+		c.abort()
+		return ctx.Err()
+	}, nil
+}
+
+// done ends an exchange that was not interrupted by a context. If the
+// exchange failed because the connection is broken (e.g. a read or write
+// deadline expired), the connection is closed.
+func (c *Client) done(err error) error {
+	if isConnError(err) {
+		c.abort()
+	}
+	return err
+}
+
+// run performs the request/response exchange f. If retry is true and the
+// client reconnects automatically, the exchange is performed again on a
+// new connection when the connection breaks during the first attempt.
+func (c *Client) run(retry bool, f func() error) error {
+	end, err := c.begin()
+	if err != nil {
+		return err
+	}
+	err = end(f())
+	if retry && c.dialOptions.reconnectAttempts > 0 && isConnError(err) {
+		if end, err = c.begin(); err != nil {
+			return err
+		}
+		err = end(f())
+	}
+	return err
+}
+
+// resetState waits until no request is in progress and returns the
+// partition chosen for c and whether tagtypes commands were sent. It fails
+// if the connection can no longer be used to send requests, or if the
+// context of c is done first, e.g. because a SongIterator still holds the
+// connection.
+func (c *Client) resetState() (partition string, tagTypes bool, err error) {
+	ctx := c.Context()
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		return "", false, ctx.Err()
+	}
+	defer func() { <-c.sem }()
+	if c.text == nil || c.isClosed() {
+		return "", false, ErrClosed
+	}
+	return c.partition, len(c.tagTypes) > 0, nil
+}
+
+// abort closes the connection without notifying the server.
+func (c *Client) abort() {
+	if c.text != nil {
+		c.text.Close()
+		c.mu.Lock()
+		c.text = nil
+		c.mu.Unlock()
+	}
+}
+
+// resync reads the rest of the response after the exchange failed with err,
+// so that the connection can be used for further requests. A response can
+// be left unread after any error but a connection error, e.g. a
+// ProtocolError. If the end of the response can't be read, the connection
+// is closed. resync returns err.
+func (c *Client) resync(err error) error {
+	if err == nil || c.text == nil || !c.inResponse || IsConnectionError(err) {
+		return err
+	}
+	for {
+		line, rerr := c.readLine()
+		if _, ok := rerr.(Error); ok || (rerr == nil && line == "OK") {
+			return err
+		}
+		if rerr != nil {
+			c.abort()
+			return err
+		}
+	}
+}
+
+// cmd sends a command, whose response must then be read.
+func (c *Client) cmd(format string, args ...interface{}) (uint, error) {
+	c.inResponse = true
+	return request(c.text, format, args...)
+}
+
 // We are reimplemeting Cmd() and PrintfLine() from textproto here, because
 // the original functions append CR-LF to the end of commands. This behavior
 // violates the MPD protocol: Commands must be terminated by '\n'.
-func (c *Client) cmd(format string, args ...interface{}) (uint, error) {
-	id := c.text.Next()
-	c.text.StartRequest(id)
-	defer c.text.EndRequest(id)
-	if err := c.printfLine(format, args...); err != nil {
+func request(text *textproto.Conn, format string, args ...interface{}) (uint, error) {
+	id := text.Next()
+	text.StartRequest(id)
+	defer text.EndRequest(id)
+	if err := printfLine(text, format, args...); err != nil {
 		return 0, err
 	}
 	return id, nil
 }
 
 func (c *Client) printfLine(format string, args ...interface{}) error {
-	fmt.Fprintf(c.text.W, format, args...)
-	c.text.W.WriteByte('\n')
-	return c.text.W.Flush()
+	return printfLine(c.text, format, args...)
+}
+
+func printfLine(text *textproto.Conn, format string, args ...interface{}) error {
+	fmt.Fprintf(text.W, format, args...)
+	text.W.WriteByte('\n')
+	return text.W.Flush()
 }
 
-// Close terminates the connection with MPD.
+// Close terminates the connection with MPD. If a request is in progress,
+// it is interrupted and fails.
 func (c *Client) Close() (err error) {
-	if c.text != nil {
-		c.printfLine("close")
-		err = c.text.Close()
-		c.text = nil
+	select {
+	case c.sem <- struct{}{}:
+		defer func() { <-c.sem }()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.closed = true
+		if c.text != nil {
+			c.printfLine("close")
+			err = c.text.Close()
+			c.text = nil
+		}
+	default:
+		// Unblock the pending request, which then fails and releases the
+		// connection. Don't wait for it: the connection may be held by a
+		// SongIterator which is not being advanced.
+		c.mu.Lock()
+		c.closed = true
+		err = c.netConn.Close()
+		c.mu.Unlock()
 	}
 	return
 }
 
+// isClosed reports whether Close was called.
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
 // Ping sends a no-op message to MPD. It's useful for keeping the connection alive.
 func (c *Client) Ping() error {
 	return c.Command("ping").OK()
 }
 
-func (c *Client) readList(key string) (list []string, err error) {
+func (c *Client) readList(key, terminator string) (list []string, err error) {
 	list = []string{}
 	key += ": "
 	for {
@@ -172,11 +462,11 @@ func (c *Client) readList(key string) (list []string, err error) {
 		if err != nil {
 			return nil, err
 		}
-		if line == "OK" {
+		if line == terminator {
 			break
 		}
 		if !strings.HasPrefix(line, key) {
-			return nil, textproto.ProtocolError("unexpected: " + line)
+			return nil, protocolError("unexpected line", line)
 		}
 		list = append(list, line[len(key):])
 	}
@@ -188,7 +478,11 @@ func (c *Client) readLine() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if line == "OK" {
+		c.inResponse = false
+	}
 	if strings.HasPrefix(line, "ACK ") {
+		c.inResponse = false
 		cur := line[4:]
 		var code, idx int
 		if strings.HasPrefix(cur, "[") {
@@ -197,11 +491,11 @@ func (c *Client) readLine() (string, error) {
 			if sep > 0 && end > 0 {
 				code, err = strconv.Atoi(cur[1:sep])
 				if err != nil {
-					return "", err
+					return "", protocolError("can't parse error code", line)
 				}
 				idx, err = strconv.Atoi(cur[sep+1 : end])
 				if err != nil {
-					return "", err
+					return "", protocolError("can't parse command list index", line)
 				}
 				cur = cur[end+2:]
 			}
@@ -234,15 +528,15 @@ func (c *Client) readBytes(length int) ([]byte, error) {
 	// Verify there's a linebreak afterwards and skip it
 	termByte, err := c.text.R.ReadByte()
 	if err != nil {
-		return nil, textproto.ProtocolError("failed to read binary data terminator: " + err.Error())
+		return nil, protocolError("failed to read binary data terminator: "+err.Error(), "")
 	}
 	if termByte != '\n' {
-		return nil, textproto.ProtocolError(fmt.Sprintf("wrong binary data terminator: want 0x0a, got %x", termByte))
+		return nil, protocolError(fmt.Sprintf("wrong binary data terminator: want 0x0a, got %x", termByte), "")
 	}
 	return data, nil
 }
 
-func (c *Client) readAttrsList(startKey string) (attrs []Attrs, err error) {
+func (c *Client) readAttrsList(startKey, terminator string) (attrs []Attrs, err error) {
 	attrs = []Attrs{}
 	startKey += ": "
 	for {
@@ -250,24 +544,93 @@ func (c *Client) readAttrsList(startKey string) (attrs []Attrs, err error) {
 		if err != nil {
 			return nil, err
 		}
-		if line == "OK" {
+		if line == terminator {
 			break
 		}
 		if strings.HasPrefix(line, startKey) { // new entry begins
 			attrs = append(attrs, Attrs{})
 		}
 		if len(attrs) == 0 {
-			return nil, textproto.ProtocolError("unexpected: " + line)
+			return nil, protocolError("unexpected line", line)
 		}
 		i := strings.Index(line, ": ")
 		if i < 0 {
-			return nil, textproto.ProtocolError("can't parse line: " + line)
+			return nil, protocolError("can't parse line", line)
 		}
 		attrs[len(attrs)-1][line[0:i]] = line[i+2:]
 	}
 	return attrs, nil
 }
 
+// readSongs reads a list of songs. Unlike readAttrsList, it keeps all the
+// values of repeated tags. Directories and playlists in the response are
+// skipped.
+func (c *Client) readSongs(terminator string) ([]Song, error) {
+	songs := []Song{}
+	r := songReader{c: c, terminator: terminator}
+	for {
+		song, err := r.read()
+		if err != nil {
+			return nil, err
+		}
+		if song == nil {
+			break
+		}
+		songs = append(songs, *song)
+	}
+	return songs, nil
+}
+
+// songReader reads a list of songs, one at a time.
+type songReader struct {
+	c          *Client
+	terminator string
+	next       *Song // song whose first line has already been read
+	done       bool  // the whole list has been read
+}
+
+// read returns the next song in the list, or nil at the end of the list.
+func (r *songReader) read() (*Song, error) {
+	song := r.next
+	r.next = nil
+	for !r.done {
+		line, err := r.c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == r.terminator {
+			r.done = true
+			break
+		}
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			return nil, protocolError("can't parse line", line)
+		}
+		key, value := line[0:i], line[i+2:]
+		switch key {
+		case "file": // new song begins
+			if song != nil {
+				r.next = newSong(value)
+				return song, nil
+			}
+			song = newSong(value)
+			continue
+		case "directory", "playlist":
+			if song != nil {
+				return song, nil
+			}
+			continue
+		}
+		if song == nil {
+			continue
+		}
+		if err := song.set(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return song, nil
+}
+
 func (c *Client) readAttrs(terminator string) (attrs Attrs, err error) {
 	attrs = make(Attrs)
 	for {
@@ -280,7 +643,7 @@ func (c *Client) readAttrs(terminator string) (attrs Attrs, err error) {
 		}
 		z := strings.Index(line, ": ")
 		if z < 0 {
-			return nil, textproto.ProtocolError("can't parse line: " + line)
+			return nil, protocolError("can't parse line", line)
 		}
 		key := line[0:z]
 		attrs[key] = line[z+2:]
@@ -288,7 +651,7 @@ func (c *Client) readAttrs(terminator string) (attrs Attrs, err error) {
 	return
 }
 
-func (c *Client) readBinary() ([]byte, int, error) {
+func (c *Client) readBinary(terminator string) ([]byte, int, error) {
 	size := -1
 	for {
 		line, err := c.readLine()
@@ -299,14 +662,14 @@ func (c *Client) readBinary() ([]byte, int, error) {
 		// Check for the size key
 		case strings.HasPrefix(line, "size: "):
 			if size, err = strconv.Atoi(line[6:]); err != nil {
-				return nil, 0, textproto.ProtocolError("failed to parse size: " + err.Error())
+				return nil, 0, protocolError("can't parse size", line)
 			}
 
 		// Check for the binary key
 		case strings.HasPrefix(line, "binary: "):
 			length := -1
 			if length, err = strconv.Atoi(line[8:]); err != nil {
-				return nil, 0, textproto.ProtocolError("failed to parse binary: " + err.Error())
+				return nil, 0, protocolError("can't parse binary", line)
 			}
 
 			// If no size is given, assume it's equal to the provided data's length
@@ -320,17 +683,17 @@ func (c *Client) readBinary() ([]byte, int, error) {
 				return nil, 0, err
 			}
 
-			// The binary data must be followed by the "OK" line
+			// The binary data must be followed by the terminator line
 			if s, err := c.readLine(); err != nil {
 				return nil, 0, err
-			} else if s != "OK" {
-				return nil, 0, textproto.ProtocolError("expected 'OK', got " + s)
+			} else if s != terminator {
+				return nil, 0, protocolError("expected "+terminator, s)
 			}
 			return data, size, nil
 
 		// No more data. Obviously, no binary data encountered
-		case line == "", line == "OK":
-			return nil, 0, textproto.ProtocolError("no binary data found in response")
+		case line == "", line == terminator:
+			return nil, 0, protocolError("no binary data found in response", "")
 		}
 	}
 }
@@ -358,18 +721,38 @@ func (c *Client) readOKLine(terminator string) (err error) {
 	if line == terminator {
 		return nil
 	}
-	return textproto.ProtocolError("unexpected response: " + line)
+	return protocolError("unexpected response", line)
 }
 
-func (c *Client) idle(subsystems ...string) ([]string, error) {
-	return c.Command("idle %s", Quoted(strings.Join(subsystems, " "))).Strings("changed")
+// idle waits until one of subsystems changes. The read timeout of the
+// connection doesn't apply while waiting.
+func (c *Client) idle(subsystems ...string) (changed []string, err error) {
+	err = c.Command("idle %s", Quoted(strings.Join(subsystems, " "))).exec(func() error {
+		if tc, ok := c.netConn.(*timeoutConn); ok {
+			defer tc.suspendReadTimeout()()
+		}
+		changed, err = c.readList("changed", "OK")
+		return err
+	})
+	return
 }
 
+// noIdle interrupts a pending idle. Unlike other requests, it doesn't wait
+// for the exchange in progress to finish.
 func (c *Client) noIdle() (err error) {
-	id, err := c.cmd("noidle")
+	// The connection may be closed concurrently by the pending idle, which
+	// holds c.sem, so c.text is read under c.mu.
+	c.mu.Lock()
+	text := c.text
+	c.mu.Unlock()
+	if text == nil {
+		return ErrClosed
+	}
+	// The response is read by the pending idle.
+	id, err := request(text, "noidle")
 	if err == nil {
-		c.text.StartResponse(id)
-		c.text.EndResponse(id)
+		text.StartResponse(id)
+		text.EndResponse(id)
 	}
 	return
 }
@@ -498,23 +881,29 @@ func (c *Client) Consume(consume bool) error {
 // song at position start. If both start and end are positive, it does it
 // for positions in range [start, end).
 func (c *Client) PlaylistInfo(start, end int) ([]Attrs, error) {
-	var cmd *Command
+	cmd, err := c.playlistInfoCommand(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.AttrsList("file")
+}
+
+func (c *Client) playlistInfoCommand(start, end int) (*Command, error) {
 	switch {
 	case start < 0 && end < 0:
 		// Request all playlist items.
-		cmd = c.Command("playlistinfo")
+		return c.Command("playlistinfo"), nil
 	case start >= 0 && end >= 0:
 		// Request this range of playlist items.
-		cmd = c.Command("playlistinfo %d:%d", start, end)
+		return c.Command("playlistinfo %d:%d", start, end), nil
 	case start >= 0 && end < 0:
 		// Request the single playlist item at this position.
-		cmd = c.Command("playlistinfo %d", start)
+		return c.Command("playlistinfo %d", start), nil
 	case start < 0 && end >= 0:
 		return nil, errors.New("negative start index")
 	default:
 		panic("unreachable")
 	}
-	return cmd.AttrsList("file")
 }
 
 // SetPriority set the priority of the specified songs. If end is negative but
@@ -599,9 +988,13 @@ func (c *Client) AddID(uri string, pos int) (int, error) {
 	}
 	tok, ok := attrs["Id"]
 	if !ok {
-		return -1, textproto.ProtocolError("addid did not return Id")
+		return -1, protocolError("addid did not return Id", "")
 	}
-	return strconv.Atoi(tok)
+	id, err := strconv.Atoi(tok)
+	if err != nil {
+		return -1, protocolError("can't parse Id", "Id: "+tok)
+	}
+	return id, nil
 }
 
 // Clear clears the current playlist.
@@ -632,25 +1025,21 @@ func (c *Client) GetFiles() ([]string, error) {
 //
 // The returned jobID identifies the update job, enqueued by MPD.
 func (c *Client) Update(uri string) (jobID int, err error) {
-	id, err := c.cmd("update %s", quote(uri))
-	if err != nil {
-		return
-	}
-	c.text.StartResponse(id)
-	defer c.text.EndResponse(id)
-
-	line, err := c.readLine()
-	if err != nil {
-		return
-	}
-	if !strings.HasPrefix(line, "updating_db: ") {
-		return 0, textproto.ProtocolError("unexpected response: " + line)
-	}
-	jobID, err = strconv.Atoi(line[13:])
-	if err != nil {
-		return
-	}
-	return jobID, c.readOKLine("OK")
+	err = c.Command("update %s", uri).exec(func() error {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "updating_db: ") {
+			return protocolError("unexpected response", line)
+		}
+		jobID, err = strconv.Atoi(line[13:])
+		if err != nil {
+			return protocolError("can't parse updating_db", line)
+		}
+		return c.readOKLine("OK")
+	})
+	return
 }
 
 // Rescan updates MPD's database like Update, but it also rescans unmodified
@@ -659,38 +1048,39 @@ func (c *Client) Update(uri string) (jobID int, err error) {
 //
 // The returned jobID identifies the update job, enqueued by MPD.
 func (c *Client) Rescan(uri string) (jobID int, err error) {
-	id, err := c.cmd("rescan %s", quote(uri))
-	if err != nil {
-		return
-	}
-	c.text.StartResponse(id)
-	defer c.text.EndResponse(id)
-
-	line, err := c.readLine()
-	if err != nil {
-		return
-	}
-	if !strings.HasPrefix(line, "updating_db: ") {
-		return 0, textproto.ProtocolError("unexpected response: " + line)
-	}
-	jobID, err = strconv.Atoi(line[13:])
-	if err != nil {
-		return
-	}
-	return jobID, c.readOKLine("OK")
+	err = c.Command("rescan %s", uri).exec(func() error {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "updating_db: ") {
+			return protocolError("unexpected response", line)
+		}
+		jobID, err = strconv.Atoi(line[13:])
+		if err != nil {
+			return protocolError("can't parse updating_db", line)
+		}
+		return c.readOKLine("OK")
+	})
+	return
 }
 
 // ListAllInfo returns attributes for songs in the library. Information about
 // any song that is either inside or matches the passed in uri is returned.
 // To get information about every song in the library, pass in "/".
-func (c *Client) ListAllInfo(uri string) ([]Attrs, error) {
-	id, err := c.cmd("listallinfo %s ", quote(uri))
+func (c *Client) ListAllInfo(uri string) (attrs []Attrs, err error) {
+	err = c.Command("listallinfo %s", uri).exec(func() error {
+		attrs, err = c.readAllInfo("OK")
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	c.text.StartResponse(id)
-	defer c.text.EndResponse(id)
+	return attrs, nil
+}
 
+// readAllInfo reads the songs listed by listallinfo, skipping directories.
+func (c *Client) readAllInfo(terminator string) ([]Attrs, error) {
 	attrs := []Attrs{}
 	inEntry := false
 	for {
@@ -698,7 +1088,7 @@ func (c *Client) ListAllInfo(uri string) ([]Attrs, error) {
 		if err != nil {
 			return nil, err
 		}
-		if line == "OK" {
+		if line == terminator {
 			break
 		} else if strings.HasPrefix(line, "file: ") { // new entry begins
 			attrs = append(attrs, Attrs{})
@@ -710,7 +1100,7 @@ func (c *Client) ListAllInfo(uri string) ([]Attrs, error) {
 		if inEntry {
 			i := strings.Index(line, ": ")
 			if i < 0 {
-				return nil, textproto.ProtocolError("can't parse line: " + line)
+				return nil, protocolError("can't parse line", line)
 			}
 			attrs[len(attrs)-1][line[0:i]] = line[i+2:]
 		}
@@ -719,20 +1109,27 @@ func (c *Client) ListAllInfo(uri string) ([]Attrs, error) {
 }
 
 // ListInfo lists the contents of the directory URI using MPD's lsinfo command.
-func (c *Client) ListInfo(uri string) ([]Attrs, error) {
-	id, err := c.cmd("lsinfo %s", quote(uri))
+func (c *Client) ListInfo(uri string) (attrs []Attrs, err error) {
+	err = c.Command("lsinfo %s", uri).exec(func() error {
+		attrs, err = c.readInfo("OK")
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	c.text.StartResponse(id)
-	defer c.text.EndResponse(id)
+	return attrs, nil
+}
+
+// readInfo reads the files, directories and playlists listed by lsinfo.
+// Keys are converted to lower case.
+func (c *Client) readInfo(terminator string) ([]Attrs, error) {
 	attrs := []Attrs{}
 	for {
 		line, err := c.readLine()
 		if err != nil {
 			return nil, err
 		}
-		if line == "OK" {
+		if line == terminator {
 			break
 		}
 		if strings.HasPrefix(line, "file: ") ||
@@ -740,9 +1137,12 @@ func (c *Client) ListInfo(uri string) ([]Attrs, error) {
 			strings.HasPrefix(line, "playlist: ") {
 			attrs = append(attrs, Attrs{})
 		}
+		if len(attrs) == 0 {
+			return nil, protocolError("unexpected line", line)
+		}
 		i := strings.Index(line, ": ")
 		if i < 0 {
-			return nil, textproto.ProtocolError("can't parse line: " + line)
+			return nil, protocolError("can't parse line", line)
 		}
 		attrs[len(attrs)-1][strings.ToLower(line[0:i])] = line[i+2:]
 	}
@@ -761,28 +1161,56 @@ func (c *Client) ReadComments(uri string) (Attrs, error) {
 //
 //	Find("artist", "Artist Name", "album", "Album Name")
 //
+// Songs matching a Filter are found with FindWithOptions. The expression
+// returned by Filter.String can also be passed as a single argument, as it
+// is: it must not be quoted again.
+//
 // Searches are case sensitive. Use Search for case insensitive search.
 func (c *Client) Find(args ...string) ([]Attrs, error) {
-	return c.Command("find " + quoteArgs(args)).AttrsList("file")
+	return c.Command("find %s", Quoted(quoteArgs(args))).AttrsList("file")
 }
 
 // Search behaves exactly the same as Find, but the searches are not case sensitive.
+// Songs matching a Filter are found with SearchWithOptions.
 func (c *Client) Search(args ...string) ([]Attrs, error) {
-	return c.Command("search " + quoteArgs(args)).AttrsList("file")
+	return c.Command("search %s", Quoted(quoteArgs(args))).AttrsList("file")
 }
 
 // List searches the database for your query. You can use something simple like
 // `artist` for your search, or something like `artist album <Album Name>` if
-// you want the artist that has an album with a specified album name.
-func (c *Client) List(args ...string) ([]string, error) {
-	id, err := c.cmd("list " + quoteArgs(args))
+// you want the artist that has an album with a specified album name. The
+// values of a tag in the songs matching a Filter are listed with
+// ListWithFilter.
+func (c *Client) List(args ...string) (ret []string, err error) {
+	err = c.Command("list %s", Quoted(quoteArgs(args))).exec(func() error {
+		ret, err = c.readValues("OK")
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	c.text.StartResponse(id)
-	defer c.text.EndResponse(id)
+	return ret, nil
+}
 
-	var ret []string
+// ListWithFilter lists the values of tag in the songs matching filter. If
+// filter is empty, all the songs are considered.
+func (c *Client) ListWithFilter(tag Tag, filter Filter) (values []string, err error) {
+	err = c.Command("list %s", Quoted(listGroupedArgs(tag, filter, nil))).exec(func() error {
+		values, err = c.readValues("OK")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = []string{}
+	}
+	return values, nil
+}
+
+// readValues reads the values of a response, whatever their key.
+func (c *Client) readValues(terminator string) ([]string, error) {
+	var values []string
 	for {
 		line, err := c.readLine()
 		if err != nil {
@@ -791,28 +1219,127 @@ func (c *Client) List(args ...string) ([]string, error) {
 
 		i := strings.Index(line, ": ")
 		if i > 0 {
-			ret = append(ret, line[i+2:])
-		} else if line == "OK" {
+			values = append(values, line[i+2:])
+		} else if line == terminator {
 			break
 		} else {
-			return nil, textproto.ProtocolError("can't parse line: " + line)
+			return nil, protocolError("can't parse line", line)
 		}
 	}
-	return ret, nil
+	return values, nil
+}
+
+// ListGrouped lists the values of tag in the songs matching filter, grouped
+// by the values of the tags in groups. If filter is empty, all the songs are
+// considered. Each returned record maps tag and the tags in groups to their
+// values, e.g. the albums of each album artist are listed with:
+//
+//	ListGrouped(TagAlbum, Filter{}, TagAlbumArtist)
+//
+// which returns records such as {"Album": "Album Name", "AlbumArtist":
+// "Artist Name"}. Groups in which a song has no value for a tag have an
+// empty value for it.
+func (c *Client) ListGrouped(tag Tag, filter Filter, groups ...Tag) (records []Attrs, err error) {
+	err = c.Command("list %s", Quoted(listGroupedArgs(tag, filter, groups))).exec(func() error {
+		records, err = c.readListGrouped(tag, "OK")
+		return err
+	})
+	return
+}
+
+// listGroupedArgs returns the quoted arguments of ListGrouped.
+func listGroupedArgs(tag Tag, filter Filter, groups []Tag) string {
+	args := quote(string(tag))
+	if !filter.IsZero() {
+		args += " " + quote(filter.String())
+	}
+	for _, g := range groups {
+		args += " group " + quote(string(g))
+	}
+	return args
+}
+
+// readListGrouped reads the response to a list command with groups. MPD
+// only writes the value of a group when it changes.
+func (c *Client) readListGrouped(tag Tag, terminator string) ([]Attrs, error) {
+	records := []Attrs{}
+	group := make(Attrs)
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == terminator {
+			break
+		}
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			return nil, protocolError("can't parse line", line)
+		}
+		key, value := line[:i], line[i+2:]
+		if !strings.EqualFold(key, string(tag)) {
+			group[key] = value
+			continue
+		}
+		record := make(Attrs, len(group)+1)
+		for k, v := range group {
+			record[k] = v
+		}
+		record[key] = value
+		records = append(records, record)
+	}
+	return records, nil
 }
 
 // Partition commands
 
-// Partition switches the client to a different partition.
+// Partition switches the client to a different partition. The partition
+// is selected again if the client reconnects. The requests of a
+// PartitionClient sharing the connection don't affect it, and calling
+// Partition on a PartitionClient doesn't change its partition.
 func (c *Client) Partition(name string) error {
-	return c.Command("partition %s", name).OK()
+	return c.Command("partition %s", name).exec(func() error {
+		if err := c.readOKLine("OK"); err != nil {
+			return err
+		}
+		c.partitionSelected(name)
+		return nil
+	})
+}
+
+// partitionSelected records that the partition name was selected by
+// Partition.
+func (c *Client) partitionSelected(name string) {
+	c.selected = name
+	if c.inPartition == "" {
+		c.partition = name
+	}
 }
 
 // ListPartitions returns a list of partitions and their information.
+// Partitions returns them as Partition values.
 func (c *Client) ListPartitions() ([]Attrs, error) {
 	return c.Command("listpartitions").AttrsList("partition")
 }
 
+// Partition is a partition of MPD, as listed by Partitions.
+type Partition struct {
+	Name string
+}
+
+// Partitions returns the partitions.
+func (c *Client) Partitions() ([]Partition, error) {
+	names, err := c.Command("listpartitions").Strings("partition")
+	if err != nil {
+		return nil, err
+	}
+	partitions := make([]Partition, len(names))
+	for i, name := range names {
+		partitions[i] = Partition{Name: name}
+	}
+	return partitions, nil
+}
+
 // NewPartition creates a new partition with the given name.
 func (c *Client) NewPartition(name string) error {
 	return c.Command("newpartition %s", name).OK()
@@ -828,13 +1355,129 @@ func (c *Client) MoveOutput(name string) error {
 	return c.Command("moveoutput %s", name).OK()
 }
 
+// Tag types commands
+
+// TagTypes returns the tag types that MPD includes in responses to this
+// client.
+func (c *Client) TagTypes() ([]string, error) {
+	return c.Command("tagtypes").Strings("tagtype")
+}
+
+// TagTypesDisable removes tags from the tag types included in responses.
+func (c *Client) TagTypesDisable(tags ...string) error {
+	return c.tagTypesCommand(false, "tagtypes disable %s", Quoted(quoteArgs(tags)))
+}
+
+// TagTypesEnable adds tags to the tag types included in responses.
+func (c *Client) TagTypesEnable(tags ...string) error {
+	return c.tagTypesCommand(false, "tagtypes enable %s", Quoted(quoteArgs(tags)))
+}
+
+// TagTypesClear removes all tag types from responses.
+func (c *Client) TagTypesClear() error {
+	return c.tagTypesCommand(true, "tagtypes clear")
+}
+
+// TagTypesAll includes all known tag types in responses.
+func (c *Client) TagTypesAll() error {
+	return c.tagTypesCommand(true, "tagtypes all")
+}
+
+// tagTypesCommand sends a tagtypes command and records it so that it can be
+// replayed after reconnecting. If reset is true, the command overrides all
+// the previous ones.
+func (c *Client) tagTypesCommand(reset bool, format string, args ...interface{}) error {
+	cmd := c.Command(format, args...)
+	return cmd.exec(func() error {
+		if err := c.readOKLine("OK"); err != nil {
+			return err
+		}
+		if reset {
+			c.tagTypes = nil
+		}
+		c.tagTypes = append(c.tagTypes, cmd.cmd)
+		return nil
+	})
+}
+
 // Output related commands.
 
 // ListOutputs lists all configured outputs with their name, id & enabled state.
+// Outputs returns more details, such as the runtime attributes.
 func (c *Client) ListOutputs() ([]Attrs, error) {
 	return c.Command("outputs").AttrsList("outputid")
 }
 
+// Output is an audio output of MPD.
+type Output struct {
+	ID      int
+	Name    string
+	Plugin  string
+	Enabled bool
+
+	// Attributes maps the names of the runtime attributes of the output,
+	// which can be changed with OutputSet, to their value.
+	Attributes map[string]string
+}
+
+// Outputs returns all configured outputs.
+func (c *Client) Outputs() (outputs []Output, err error) {
+	err = c.Command("outputs").exec(func() error {
+		outputs, err = c.readOutputs()
+		return err
+	})
+	return
+}
+
+// readOutputs reads a list of outputs. Unlike readAttrsList, it keeps the
+// values of all the attribute lines.
+func (c *Client) readOutputs() ([]Output, error) {
+	outputs := []Output{}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "OK" {
+			break
+		}
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			return nil, protocolError("can't parse line", line)
+		}
+		key, value := line[:i], line[i+2:]
+		if key == "outputid" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, protocolError("can't parse outputid", line)
+			}
+			outputs = append(outputs, Output{ID: id, Attributes: make(map[string]string)})
+			continue
+		}
+		if len(outputs) == 0 {
+			return nil, protocolError("unexpected line", line)
+		}
+		o := &outputs[len(outputs)-1]
+		switch key {
+		case "outputname":
+			o.Name = value
+		case "plugin":
+			o.Plugin = value
+		case "outputenabled":
+			if o.Enabled, err = parseBool(value); err != nil {
+				return nil, protocolError("can't parse outputenabled", line)
+			}
+		case "attribute":
+			j := strings.IndexByte(value, '=')
+			if j < 0 {
+				return nil, protocolError("can't parse attribute", line)
+			}
+			o.Attributes[value[:j]] = value[j+1:]
+		}
+	}
+	return outputs, nil
+}
+
 // EnableOutput enables the audio output with the given id.
 func (c *Client) EnableOutput(id int) error {
 	return c.Command("enableoutput %d", id).OK()
@@ -845,6 +1488,18 @@ func (c *Client) DisableOutput(id int) error {
 	return c.Command("disableoutput %d", id).OK()
 }
 
+// ToggleOutput enables the audio output with the given id if it is
+// disabled, and disables it otherwise.
+func (c *Client) ToggleOutput(id int) error {
+	return c.Command("toggleoutput %d", id).OK()
+}
+
+// OutputSet sets the runtime attribute name of the audio output with the
+// given id to value, e.g. "dop" to "1" to enable DSD over PCM.
+func (c *Client) OutputSet(id int, name, value string) error {
+	return c.Command("outputset %d %s %s", id, name, value).OK()
+}
+
 // Stored playlists related commands
 
 // ListPlaylists lists all stored playlists.
@@ -926,7 +1581,7 @@ func parseSticker(s string) (*Sticker, error) {
 	// (e.g. base64 encoded data -- see #39).
 	i := strings.Index(s, "=")
 	if i < 0 {
-		return nil, textproto.ProtocolError("parsing sticker failed")
+		return nil, protocolError("can't parse sticker", s)
 	}
 	return newSticker(s[:i], s[i+1:]), nil
 }
@@ -943,14 +1598,19 @@ func (c *Client) StickerFind(uri string, name string) ([]string, []Sticker, erro
 	if err != nil {
 		return nil, nil, err
 	}
+	return parseStickerFind(attrs)
+}
+
+// parseStickerFind parses the files and stickers found by sticker find.
+func parseStickerFind(attrs []Attrs) ([]string, []Sticker, error) {
 	files := make([]string, len(attrs))
 	stks := make([]Sticker, len(attrs))
 	for i, attr := range attrs {
 		if _, ok := attr["file"]; !ok {
-			return nil, nil, textproto.ProtocolError("file attribute not found")
+			return nil, nil, protocolError("file attribute not found", "")
 		}
 		if _, ok := attr["sticker"]; !ok {
-			return nil, nil, textproto.ProtocolError("sticker attribute not found")
+			return nil, nil, protocolError("sticker attribute not found", "")
 		}
 		files[i] = attr["file"]
 		stk, err := parseSticker(attr["sticker"])
@@ -968,9 +1628,14 @@ func (c *Client) StickerGet(uri string, name string) (*Sticker, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseStickerGet(attrs)
+}
+
+// parseStickerGet parses the sticker returned by sticker get.
+func parseStickerGet(attrs Attrs) (*Sticker, error) {
 	attr, ok := attrs["sticker"]
 	if !ok {
-		return nil, textproto.ProtocolError("sticker not found")
+		return nil, protocolError("sticker not found", "")
 	}
 	stk, err := parseSticker(attr)
 	if stk == nil {
@@ -985,11 +1650,16 @@ func (c *Client) StickerList(uri string) ([]Sticker, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseStickerList(attrs)
+}
+
+// parseStickerList parses the stickers listed by sticker list.
+func parseStickerList(attrs []Attrs) ([]Sticker, error) {
 	stks := make([]Sticker, len(attrs))
 	for i, attr := range attrs {
 		s, ok := attr["sticker"]
 		if !ok {
-			return nil, textproto.ProtocolError("sticker attribute not found")
+			return nil, protocolError("sticker attribute not found", "")
 		}
 		stk, err := parseSticker(s)
 		if err != nil {
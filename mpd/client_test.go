@@ -5,9 +5,15 @@
 package mpd
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/fhs/gompd/v2/mpd/internal/server"
 )
@@ -83,22 +89,61 @@ func localAddr() (net, addr string) {
 	return net, addr + ":" + port
 }
 
-func localDial(t testing.TB) *Client {
+// localServer returns the address of the MPD server used by the tests,
+// starting the test server if needed.
+func localServer(t testing.TB) (net, addr string) {
 	t.Helper()
-	net, addr := localAddr()
+	net, addr = localAddr()
 	if useGoMPDServer && !serverRunning {
 		running := make(chan bool)
 		go server.Listen(net, addr, running)
 		serverRunning = true
 		<-running
 	}
-	cli, err := Dial(net, addr)
+	return net, addr
+}
+
+func localDial(t testing.TB, opts ...DialOption) *Client {
+	t.Helper()
+	net, addr := localServer(t)
+	cli, err := DialWithOptions(net, addr, opts...)
 	if err != nil {
 		t.Fatalf("Dial(%q) = %v, %s want PTR, nil", addr, cli, err)
 	}
 	return cli
 }
 
+// pipeDial returns a dial function connecting to an in-memory server. The
+// server sends greeting, if not empty, then reads the commands one at a
+// time and answers the i-th one with replies[i] before reading the next
+// one. Once out of replies, it reads the commands without answering them
+// until the connection is closed.
+func pipeDial(greeting string, replies ...string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			if greeting != "" {
+				if _, err := server.Write([]byte(greeting + "\n")); err != nil {
+					return
+				}
+			}
+			r := bufio.NewReader(server)
+			for i := 0; ; i++ {
+				if _, err := r.ReadString('\n'); err != nil {
+					return
+				}
+				if i < len(replies) {
+					if _, err := server.Write([]byte(replies[i])); err != nil {
+						return
+					}
+				}
+			}
+		}()
+		return client, nil
+	}
+}
+
 func teardown(cli *Client, t testing.TB) {
 	t.Helper()
 	cli.Clear()
@@ -354,21 +399,25 @@ func TestListOutputs(t *testing.T) {
 	if err != nil {
 		t.Fatalf(`Client.ListOutputs() = %v, %s need _, nil`, outputs, err)
 	}
+	// Only the last attribute is kept in Attrs; see Outputs.
 	expected := []Attrs{{
 		"outputid":      "0",
 		"outputname":    "downstairs",
+		"plugin":        "alsa",
 		"outputenabled": "1",
+		"attribute":     "dop=0",
 	}, {
 		"outputid":      "1",
 		"outputname":    "upstairs",
+		"plugin":        "pulse",
 		"outputenabled": "0",
 	}}
 	if len(outputs) != 2 {
 		t.Errorf(`Listed %d outputs, expected %d`, len(outputs), 2)
 	}
 	for i, o := range outputs {
-		if len(o) != 3 {
-			t.Errorf(`Output should contain 3 keys, got %d`, len(o))
+		if len(o) != len(expected[i]) {
+			t.Errorf(`Output should contain %d keys, got %d`, len(expected[i]), len(o))
 		}
 		for k, v := range expected[i] {
 			if outputs[i][k] != v {
@@ -387,6 +436,58 @@ func TestEnableOutput(t *testing.T) {
 	}
 }
 
+func TestOutputs(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	if err := cli.OutputSet(0, "dop", "1"); err != nil {
+		t.Fatalf("Client.OutputSet failed: %s", err)
+	}
+	defer cli.OutputSet(0, "dop", "0")
+	if err := cli.OutputSet(0, "bogus", "1"); err == nil {
+		t.Errorf("Client.OutputSet succeeded with an unknown attribute")
+	}
+	// Output 1 is disabled, then toggled, and restored at the end.
+	outputs, err := cli.Outputs()
+	if err != nil {
+		t.Fatalf("Client.Outputs failed: %s", err)
+	}
+	if outputs[1].Enabled {
+		defer cli.EnableOutput(1)
+	} else {
+		defer cli.DisableOutput(1)
+	}
+	if err := cli.DisableOutput(1); err != nil {
+		t.Fatalf("Client.DisableOutput failed: %s", err)
+	}
+	if err := cli.ToggleOutput(1); err != nil {
+		t.Fatalf("Client.ToggleOutput failed: %s", err)
+	}
+	outputs, err = cli.Outputs()
+	if err != nil {
+		t.Fatalf("Client.Outputs failed: %s", err)
+	}
+	want := []Output{{
+		ID:         0,
+		Name:       "downstairs",
+		Plugin:     "alsa",
+		Enabled:    true,
+		Attributes: map[string]string{"allowed_formats": "", "dop": "1"},
+	}, {
+		ID:         1,
+		Name:       "upstairs",
+		Plugin:     "pulse",
+		Enabled:    true,
+		Attributes: map[string]string{},
+	}}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Errorf("Client.Outputs returned %+v; want %+v", outputs, want)
+	}
+	if err := cli.ToggleOutput(5); err == nil {
+		t.Errorf("Client.ToggleOutput succeeded with a missing output")
+	}
+}
+
 func TestDisableOutput(t *testing.T) {
 	cli := localDial(t)
 	defer teardown(cli, t)
@@ -680,3 +781,121 @@ func TestReadPicture(t *testing.T) {
 		})
 	}
 }
+
+func TestWithContext(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cli.WithContext(ctx).Ping(); err != context.Canceled {
+		t.Fatalf("Client.Ping with canceled context = %v; want %v", err, context.Canceled)
+	}
+	// The connection is untouched if the request was never sent.
+	if err := cli.Ping(); err != nil {
+		t.Fatalf("Client.Ping failed: %s", err)
+	}
+	if cli.WithContext(ctx).Context() != ctx {
+		t.Errorf("Client.WithContext did not set the context")
+	}
+}
+
+func TestWithContextTimeout(t *testing.T) {
+	cli := localDial(t)
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	// Nothing else happens on the server, so idle blocks until the
+	// deadline is exceeded.
+	if _, err := cli.WithContext(ctx).idle("sticker"); err != context.DeadlineExceeded {
+		t.Fatalf("Client.idle = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if err := cli.Ping(); err != ErrClosed {
+		t.Fatalf("Client.Ping after interrupted request = %v; want %v", err, ErrClosed)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+	if err := cli.Clear(); err != nil {
+		t.Fatalf("Client.Clear failed: %s", err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := cli.Add(fmt.Sprintf("song%04d.ogg", i)); err != nil {
+			t.Fatalf("Client.Add failed: %s", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errc := make(chan error, 40)
+	for i := 0; i < 10; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			attrs, err := cli.Status()
+			if err == nil && attrs["state"] == "" {
+				err = fmt.Errorf("Status returned %v; want state", attrs)
+			}
+			errc <- err
+		}()
+		go func() {
+			defer wg.Done()
+			pls, err := cli.PlaylistInfo(-1, -1)
+			if err == nil && len(pls) != 4 {
+				err = fmt.Errorf("PlaylistInfo returned %d songs; want 4", len(pls))
+			}
+			errc <- err
+		}()
+		go func() {
+			defer wg.Done()
+			ls, err := cli.ListInfo("foo")
+			if err == nil && len(ls) != 103 {
+				err = fmt.Errorf("ListInfo returned %d entries; want 103", len(ls))
+			}
+			errc <- err
+		}()
+		go func() {
+			defer wg.Done()
+			cl := cli.BeginCommandList()
+			cl.Ping()
+			pa := cl.Status()
+			err := cl.End()
+			if err == nil {
+				if attrs, _ := pa.Value(); attrs["state"] == "" {
+					err = fmt.Errorf("CommandList.Status returned %v; want state", attrs)
+				}
+			}
+			errc <- err
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCloseInterruptsRequest(t *testing.T) {
+	cli := localDial(t)
+
+	errc := make(chan error)
+	go func() {
+		_, err := cli.idle("sticker")
+		errc <- err
+	}()
+	// Give idle a chance to start.
+	time.Sleep(50 * time.Millisecond)
+	if err := cli.Close(); err != nil {
+		t.Errorf("Client.Close failed: %s", err)
+	}
+	if err := <-errc; err == nil {
+		t.Errorf("Client.idle succeeded after Close")
+	}
+	if err := cli.Ping(); err != ErrClosed {
+		t.Errorf("Client.Ping after Close = %v; want %v", err, ErrClosed)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 )
 
 type command struct {
@@ -18,6 +19,10 @@ type command struct {
 // CommandList is for batch/mass MPD commands.
 // See http://www.musicpd.org/doc/protocol/command_lists.html
 // for more details.
+//
+// Most commands for which Client returns typed values, such as Songs or
+// Outputs, promise the raw attributes of the response instead, e.g.
+// PlaylistFind promises the attributes of each song.
 type CommandList struct {
 	client *Client
 	cmds   []command
@@ -29,6 +34,54 @@ type PromisedAttrs struct{ a Attrs }
 // PromisedID is a promised identifier (to be) returned by MPD.
 type PromisedID int
 
+// PromisedVolume is a promised volume (to be) returned by MPD.
+type PromisedVolume struct {
+	vol  int
+	done bool
+}
+
+// PromisedReplayGain is a promised replay gain mode (to be) returned by MPD.
+type PromisedReplayGain struct{ mode ReplayGain }
+
+// PromisedCount is a promised list of song counts (to be) returned by MPD.
+type PromisedCount struct{ counts []CountResult }
+
+// PromisedAttrsList is a promised list of attributes (to be) returned by
+// MPD.
+type PromisedAttrsList struct {
+	list []Attrs
+	read func(terminator string) ([]Attrs, error)
+}
+
+// PromisedStrings is a promised list of strings (to be) returned by MPD.
+type PromisedStrings struct {
+	list []string
+	read func(terminator string) ([]string, error)
+}
+
+// PromisedBinary is a promised chunk of binary data (to be) returned by MPD.
+type PromisedBinary struct {
+	data []byte
+	size int
+}
+
+// PromisedSticker is a promised sticker (to be) returned by MPD.
+type PromisedSticker struct{ s *Sticker }
+
+// PromisedStickers is a promised list of stickers (to be) returned by MPD.
+type PromisedStickers struct {
+	uris     []string
+	stickers []Sticker
+	find     bool // response to sticker find, which lists the song URIs
+}
+
+// promisedSongs is a promised list of songs (to be) returned by MPD.
+type promisedSongs struct{ songs []Song }
+
+// onOK is a promise of an OK response, which calls the function once it is
+// received.
+type onOK func()
+
 // Value returns the Attrs that were computed when CommandList.End was
 // called. Returns an error if CommandList.End has not yet been called.
 func (pa *PromisedAttrs) Value() (Attrs, error) {
@@ -47,12 +100,138 @@ func (pi *PromisedID) Value() (int, error) {
 	return int(*pi), nil
 }
 
+// Value returns the volume that was computed when CommandList.End was
+// called, or -1 if MPD has no mixer. Returns an error if CommandList.End has
+// not yet been called.
+func (pv *PromisedVolume) Value() (int, error) {
+	if !pv.done {
+		return -1, errors.New("value has not been computed yet")
+	}
+	return pv.vol, nil
+}
+
+// Value returns the replay gain mode that was computed when CommandList.End
+// was called. Returns an error if CommandList.End has not yet been called.
+func (pr *PromisedReplayGain) Value() (ReplayGain, error) {
+	if pr.mode == "" {
+		return "", errors.New("value has not been computed yet")
+	}
+	return pr.mode, nil
+}
+
+// Value returns the counts that were computed when CommandList.End was
+// called. Returns an error if CommandList.End has not yet been called.
+func (pc *PromisedCount) Value() ([]CountResult, error) {
+	if pc.counts == nil {
+		return nil, errors.New("value has not been computed yet")
+	}
+	return pc.counts, nil
+}
+
+// Value returns the list of Attrs that was computed when CommandList.End
+// was called. Returns an error if CommandList.End has not yet been called.
+func (pl *PromisedAttrsList) Value() ([]Attrs, error) {
+	if pl.list == nil {
+		return nil, errors.New("value has not been computed yet")
+	}
+	return pl.list, nil
+}
+
+// Value returns the strings that were computed when CommandList.End was
+// called. Returns an error if CommandList.End has not yet been called.
+func (ps *PromisedStrings) Value() ([]string, error) {
+	if ps.list == nil {
+		return nil, errors.New("value has not been computed yet")
+	}
+	return ps.list, nil
+}
+
+// Value returns the chunk of data that was computed when CommandList.End
+// was called, and the total size of the data, which can be greater than the
+// chunk. Returns an error if CommandList.End has not yet been called.
+func (pb *PromisedBinary) Value() ([]byte, int, error) {
+	if pb.data == nil {
+		return nil, 0, errors.New("value has not been computed yet")
+	}
+	return pb.data, pb.size, nil
+}
+
+// Value returns the Sticker that was computed when CommandList.End was
+// called. Returns an error if CommandList.End has not yet been called.
+func (ps *PromisedSticker) Value() (*Sticker, error) {
+	if ps.s == nil {
+		return nil, errors.New("value has not been computed yet")
+	}
+	return ps.s, nil
+}
+
+// Value returns the stickers that were computed when CommandList.End was
+// called. Returns an error if CommandList.End has not yet been called.
+func (ps *PromisedStickers) Value() ([]Sticker, error) {
+	if ps.stickers == nil {
+		return nil, errors.New("value has not been computed yet")
+	}
+	return ps.stickers, nil
+}
+
+// URIs returns the URIs of the songs of the stickers found by StickerFind,
+// in the same order as the stickers. Returns an error if CommandList.End has
+// not yet been called, or if the stickers were not found by StickerFind.
+func (ps *PromisedStickers) URIs() ([]string, error) {
+	if !ps.find {
+		return nil, errors.New("stickers were not found by StickerFind")
+	}
+	if ps.uris == nil {
+		return nil, errors.New("value has not been computed yet")
+	}
+	return ps.uris, nil
+}
+
 // BeginCommandList creates a new CommandList structure using
 // this connection.
 func (c *Client) BeginCommandList() *CommandList {
 	return &CommandList{client: c}
 }
 
+// attrs adds cmd, whose response is a set of attributes.
+func (cl *CommandList) attrs(cmd string) *PromisedAttrs {
+	var pa PromisedAttrs
+	cl.cmds = append(cl.cmds, command{promise: &pa, cmd: cmd})
+	return &pa
+}
+
+// attrsList adds cmd, whose response is a list of attributes in which each
+// entry starts with key startKey.
+func (cl *CommandList) attrsList(cmd, startKey string) *PromisedAttrsList {
+	return cl.attrsListWith(cmd, func(terminator string) ([]Attrs, error) {
+		return cl.client.readAttrsList(startKey, terminator)
+	})
+}
+
+// attrsListWith adds cmd, whose response is a list of attributes read with
+// read.
+func (cl *CommandList) attrsListWith(cmd string, read func(terminator string) ([]Attrs, error)) *PromisedAttrsList {
+	pl := &PromisedAttrsList{read: read}
+	cl.cmds = append(cl.cmds, command{promise: pl, cmd: cmd})
+	return pl
+}
+
+// songs adds cmd, whose response is a list of songs.
+func (cl *CommandList) songs(cmd string) *promisedSongs {
+	ps := new(promisedSongs)
+	cl.cmds = append(cl.cmds, command{promise: ps, cmd: cmd})
+	return ps
+}
+
+// strings adds cmd, whose response is a list of strings with key key.
+func (cl *CommandList) strings(cmd, key string) *PromisedStrings {
+	ps := &PromisedStrings{read: func(terminator string) ([]string, error) {
+		return cl.client.readList(key, terminator)
+	}}
+	cl.cmds = append(cl.cmds, command{promise: ps, cmd: cmd})
+	return ps
+}
+
 // Ping sends a no-op message to MPD. It's useful for keeping the connection alive.
 func (cl *CommandList) Ping() {
 	cl.cmds = append(cl.cmds, command{cmd: "ping"})
@@ -72,6 +251,11 @@ func (cl *CommandList) Status() *PromisedAttrs {
 	return &pa
 }
 
+// Stats displays statistics (number of artists, songs, playtime, etc)
+func (cl *CommandList) Stats() *PromisedAttrs {
+	return cl.attrs("stats")
+}
+
 //
 // Playback control
 //
@@ -126,6 +310,26 @@ func (cl *CommandList) SeekID(id, time int) {
 	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("seekid %d %d", id, time)})
 }
 
+// SeekPos seeks to the position d of the song at playlist position pos.
+func (cl *CommandList) SeekPos(pos int, d time.Duration) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("seek %d %f", pos, d.Seconds())})
+}
+
+// SeekSongID seeks to the position d of the song identified by id.
+func (cl *CommandList) SeekSongID(id int, d time.Duration) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("seekid %d %f", id, d.Seconds())})
+}
+
+// SeekCur seeks to the position d within the current song.
+// If relative is true, then the time is relative to the current playing position.
+func (cl *CommandList) SeekCur(d time.Duration, relative bool) {
+	if relative {
+		cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("seekcur %+f", d.Seconds())})
+	} else {
+		cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("seekcur %f", d.Seconds())})
+	}
+}
+
 // Stop stops playback.
 func (cl *CommandList) Stop() {
 	cl.cmds = append(cl.cmds, command{cmd: "stop"})
@@ -172,10 +376,77 @@ func (cl *CommandList) Consume(consume bool) {
 	}
 }
 
+// SetSingle sets the mode of the single option.
+func (cl *CommandList) SetSingle(mode SingleMode) {
+	cl.cmds = append(cl.cmds, command{cmd: "single " + mode.String()})
+}
+
+// SetConsume sets the mode of the consume option.
+func (cl *CommandList) SetConsume(mode ConsumeMode) {
+	cl.cmds = append(cl.cmds, command{cmd: "consume " + mode.String()})
+}
+
+// Crossfade sets the crossfading between songs to d, rounded to the second.
+func (cl *CommandList) Crossfade(d time.Duration) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("crossfade %d", roundSeconds(d))})
+}
+
+// MixRampDB sets the threshold, in decibels, at which songs overlap with
+// MixRamp.
+func (cl *CommandList) MixRampDB(db float64) {
+	cl.cmds = append(cl.cmds, command{cmd: "mixrampdb " + formatFloat(db)})
+}
+
+// MixRampDelay sets the delay subtracted from the overlap computed with
+// MixRamp. A negative delay disables MixRamp.
+func (cl *CommandList) MixRampDelay(d time.Duration) {
+	cl.cmds = append(cl.cmds, command{cmd: "mixrampdelay " + mixRampDelayArg(d)})
+}
+
+// ReplayGainMode sets the replay gain mode.
+func (cl *CommandList) ReplayGainMode(mode ReplayGain) {
+	cl.cmds = append(cl.cmds, command{cmd: "replay_gain_mode " + quote(string(mode))})
+}
+
+// ReplayGainStatus returns the replay gain mode.
+func (cl *CommandList) ReplayGainStatus() *PromisedReplayGain {
+	var pr PromisedReplayGain
+	cl.cmds = append(cl.cmds, command{promise: &pr, cmd: "replay_gain_status"})
+	return &pr
+}
+
+// GetVol returns the volume, in the range 0-100, or -1 if MPD has no
+// mixer.
+func (cl *CommandList) GetVol() *PromisedVolume {
+	var pv PromisedVolume
+	cl.cmds = append(cl.cmds, command{promise: &pv, cmd: "getvol"})
+	return &pv
+}
+
+// Volume changes the volume by change, which may be negative.
+func (cl *CommandList) Volume(change int) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("volume %d", change)})
+}
+
 //
 // Playlist related functions
 //
 
+// PlaylistInfo returns attributes for songs in the current playlist. See
+// Client.PlaylistInfo for the meaning of start and end.
+func (cl *CommandList) PlaylistInfo(start, end int) (*PromisedAttrsList, error) {
+	switch {
+	case start < 0 && end < 0:
+		return cl.attrsList("playlistinfo", "file"), nil
+	case start >= 0 && end >= 0:
+		return cl.attrsList(fmt.Sprintf("playlistinfo %d:%d", start, end), "file"), nil
+	case start >= 0 && end < 0:
+		return cl.attrsList(fmt.Sprintf("playlistinfo %d", start), "file"), nil
+	default:
+		return nil, errors.New("negative start index")
+	}
+}
+
 // SetPriority sets the priority for songs in the playlist. If both start and
 // end are non-negative, it updates those at positions in range [start, end).
 // If end is negative, it updates the song at position start.
@@ -253,6 +524,38 @@ func (cl *CommandList) AddID(uri string, pos int) *PromisedID {
 	return &id
 }
 
+// FindAdd adds the songs matching filter to the queue, at position pos.
+// See Client.FindAdd for details.
+func (cl *CommandList) FindAdd(filter Filter, opts *FindOptions, pos int) {
+	cl.cmds = append(cl.cmds, command{cmd: "findadd " + addArgs(filter, opts, pos)})
+}
+
+// SearchAdd is like FindAdd, but the search is not case sensitive.
+func (cl *CommandList) SearchAdd(filter Filter, opts *FindOptions, pos int) {
+	cl.cmds = append(cl.cmds, command{cmd: "searchadd " + addArgs(filter, opts, pos)})
+}
+
+// SearchAddPl is like SearchAdd, but adds the songs to the stored playlist
+// name.
+func (cl *CommandList) SearchAddPl(name string, filter Filter, opts *FindOptions, pos int) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("searchaddpl %s %s", quote(name), addArgs(filter, opts, pos))})
+}
+
+// Count counts the songs matching filter, grouped by the tags in groupBy.
+// See Client.Count for details.
+func (cl *CommandList) Count(filter Filter, groupBy ...Tag) *PromisedCount {
+	var pc PromisedCount
+	cl.cmds = append(cl.cmds, command{promise: &pc, cmd: "count " + countArgs(filter, groupBy)})
+	return &pc
+}
+
+// SearchCount is like Count, but the search is not case sensitive.
+func (cl *CommandList) SearchCount(filter Filter, groupBy ...Tag) *PromisedCount {
+	var pc PromisedCount
+	cl.cmds = append(cl.cmds, command{promise: &pc, cmd: "searchcount " + countArgs(filter, groupBy)})
+	return &pc
+}
+
 // Clear clears the current playlist.
 func (cl *CommandList) Clear() {
 	cl.cmds = append(cl.cmds, command{cmd: "clear"})
@@ -269,6 +572,42 @@ func (cl *CommandList) Shuffle(start, end int) {
 	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("shuffle %d:%d", start, end)})
 }
 
+// PlaylistID returns attributes for the song identified by id in the
+// current playlist.
+func (cl *CommandList) PlaylistID(id int) *PromisedAttrs {
+	return cl.attrs(fmt.Sprintf("playlistid %d", id))
+}
+
+// PlaylistFind returns attributes for the songs of the current playlist
+// matching filter.
+func (cl *CommandList) PlaylistFind(filter Filter) *PromisedAttrsList {
+	return cl.attrsList("playlistfind "+quote(filter.arg()), "file")
+}
+
+// PlaylistSearch is like PlaylistFind, but the search is not case sensitive.
+func (cl *CommandList) PlaylistSearch(filter Filter) *PromisedAttrsList {
+	return cl.attrsList("playlistsearch "+quote(filter.arg()), "file")
+}
+
+// PlChanges returns attributes for the songs of the current playlist that
+// changed since version. See Client.PlChanges for the meaning of start and
+// end.
+func (cl *CommandList) PlChanges(version, start, end int) (*PromisedAttrsList, error) {
+	if start < 0 || end < 0 {
+		return cl.attrsList(fmt.Sprintf("plchanges %d", version), "file"), nil
+	}
+	if start > end {
+		return nil, fmt.Errorf("invalid range: %d:%d", start, end)
+	}
+	return cl.attrsList(fmt.Sprintf("plchanges %d %d:%d", version, start, end), "file"), nil
+}
+
+// PlChangesPosID returns the positions ("cpos") and ids ("Id") of the songs
+// of the current playlist that changed since version.
+func (cl *CommandList) PlChangesPosID(version int) *PromisedAttrsList {
+	return cl.attrsList(fmt.Sprintf("plchangesposid %d", version), "cpos")
+}
+
 // Update updates MPD's database: find new files, remove deleted files, update
 // modified files. uri is a particular directory or file to update. If it is an
 // empty string, everything is updated.
@@ -278,8 +617,191 @@ func (cl *CommandList) Update(uri string) *PromisedAttrs {
 	return &pa
 }
 
+// Rescan updates MPD's database like Update, but it also rescans unmodified
+// files.
+func (cl *CommandList) Rescan(uri string) *PromisedAttrs {
+	return cl.attrs("rescan " + quote(uri))
+}
+
+// GetFiles returns the entire list of files in MPD database.
+func (cl *CommandList) GetFiles() *PromisedStrings {
+	return cl.strings("list file", "file")
+}
+
+// ListAllInfo returns attributes for songs in the library. See
+// Client.ListAllInfo for details.
+func (cl *CommandList) ListAllInfo(uri string) *PromisedAttrsList {
+	return cl.attrsListWith("listallinfo "+quote(uri), cl.client.readAllInfo)
+}
+
+// ListInfo lists the contents of the directory URI using MPD's lsinfo command.
+func (cl *CommandList) ListInfo(uri string) *PromisedAttrsList {
+	return cl.attrsListWith("lsinfo "+quote(uri), cl.client.readInfo)
+}
+
+// ReadComments reads "comments" (audio metadata) from the song URI using
+// MPD's readcomments command.
+func (cl *CommandList) ReadComments(uri string) *PromisedAttrs {
+	return cl.attrs("readcomments " + quote(uri))
+}
+
+// Find searches the library for songs and returns attributes for each
+// matching song. See Client.Find for details.
+func (cl *CommandList) Find(args ...string) *PromisedAttrsList {
+	return cl.attrsList("find "+quoteArgs(args), "file")
+}
+
+// Search behaves exactly the same as Find, but the searches are not case sensitive.
+func (cl *CommandList) Search(args ...string) *PromisedAttrsList {
+	return cl.attrsList("search "+quoteArgs(args), "file")
+}
+
+// FindWithOptions returns the songs matching filter, like Find, with options
+// opts, which may be nil.
+func (cl *CommandList) FindWithOptions(filter Filter, opts *FindOptions) *PromisedAttrsList {
+	return cl.attrsList("find "+findArgs(filter, opts), "file")
+}
+
+// SearchWithOptions is like FindWithOptions, but the search is not case
+// sensitive.
+func (cl *CommandList) SearchWithOptions(filter Filter, opts *FindOptions) *PromisedAttrsList {
+	return cl.attrsList("search "+findArgs(filter, opts), "file")
+}
+
+// List searches the database for your query. See Client.List for details.
+func (cl *CommandList) List(args ...string) *PromisedStrings {
+	ps := &PromisedStrings{read: cl.client.readValues}
+	cl.cmds = append(cl.cmds, command{promise: ps, cmd: "list " + quoteArgs(args)})
+	return ps
+}
+
+// ListWithFilter lists the values of tag in the songs matching filter. See
+// Client.ListWithFilter for details.
+func (cl *CommandList) ListWithFilter(tag Tag, filter Filter) *PromisedStrings {
+	ps := &PromisedStrings{read: cl.client.readValues}
+	cl.cmds = append(cl.cmds, command{promise: ps, cmd: "list " + listGroupedArgs(tag, filter, nil)})
+	return ps
+}
+
+// ListGrouped lists the values of tag in the songs matching filter, grouped
+// by the values of the tags in groups. See Client.ListGrouped for details.
+func (cl *CommandList) ListGrouped(tag Tag, filter Filter, groups ...Tag) *PromisedAttrsList {
+	return cl.attrsListWith("list "+listGroupedArgs(tag, filter, groups), func(terminator string) ([]Attrs, error) {
+		return cl.client.readListGrouped(tag, terminator)
+	})
+}
+
+// Partition commands
+
+// Partition switches the client to a different partition. The partition
+// is selected again if the client reconnects.
+func (cl *CommandList) Partition(name string) {
+	c := cl.client
+	cl.cmds = append(cl.cmds, command{promise: onOK(func() { c.partitionSelected(name) }), cmd: "partition " + quote(name)})
+}
+
+// ListPartitions returns a list of partitions and their information.
+func (cl *CommandList) ListPartitions() *PromisedAttrsList {
+	return cl.attrsList("listpartitions", "partition")
+}
+
+// NewPartition creates a new partition with the given name.
+func (cl *CommandList) NewPartition(name string) {
+	cl.cmds = append(cl.cmds, command{cmd: "newpartition " + quote(name)})
+}
+
+// DelPartition deletes partition with the given name.
+func (cl *CommandList) DelPartition(name string) {
+	cl.cmds = append(cl.cmds, command{cmd: "delpartition " + quote(name)})
+}
+
+// MoveOutput moves an output with the given name to the current partition.
+func (cl *CommandList) MoveOutput(name string) {
+	cl.cmds = append(cl.cmds, command{cmd: "moveoutput " + quote(name)})
+}
+
+// Tag types commands
+
+// TagTypes returns the tag types that MPD includes in responses to this
+// client.
+func (cl *CommandList) TagTypes() *PromisedStrings {
+	return cl.strings("tagtypes", "tagtype")
+}
+
+// TagTypesDisable removes tags from the tag types included in responses.
+func (cl *CommandList) TagTypesDisable(tags ...string) {
+	cl.tagTypesCommand(false, "tagtypes disable "+quoteArgs(tags))
+}
+
+// TagTypesEnable adds tags to the tag types included in responses.
+func (cl *CommandList) TagTypesEnable(tags ...string) {
+	cl.tagTypesCommand(false, "tagtypes enable "+quoteArgs(tags))
+}
+
+// TagTypesClear removes all tag types from responses.
+func (cl *CommandList) TagTypesClear() {
+	cl.tagTypesCommand(true, "tagtypes clear")
+}
+
+// TagTypesAll includes all known tag types in responses.
+func (cl *CommandList) TagTypesAll() {
+	cl.tagTypesCommand(true, "tagtypes all")
+}
+
+// tagTypesCommand adds a tagtypes command, which is recorded like in
+// Client.tagTypesCommand once it succeeds.
+func (cl *CommandList) tagTypesCommand(reset bool, cmd string) {
+	c := cl.client
+	cl.cmds = append(cl.cmds, command{promise: onOK(func() {
+		if reset {
+			c.tagTypes = nil
+		}
+		c.tagTypes = append(c.tagTypes, cmd)
+	}), cmd: cmd})
+}
+
+// Output related commands.
+
+// ListOutputs lists all configured outputs with their name, id & enabled state.
+func (cl *CommandList) ListOutputs() *PromisedAttrsList {
+	return cl.attrsList("outputs", "outputid")
+}
+
+// EnableOutput enables the audio output with the given id.
+func (cl *CommandList) EnableOutput(id int) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("enableoutput %d", id)})
+}
+
+// DisableOutput disables the audio output with the given id.
+func (cl *CommandList) DisableOutput(id int) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("disableoutput %d", id)})
+}
+
+// ToggleOutput enables the audio output with the given id if it is
+// disabled, and disables it otherwise.
+func (cl *CommandList) ToggleOutput(id int) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("toggleoutput %d", id)})
+}
+
+// OutputSet sets the runtime attribute name of the audio output with the
+// given id to value.
+func (cl *CommandList) OutputSet(id int, name, value string) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("outputset %d %s %s", id, quote(name), quote(value))})
+}
+
 // Stored playlists related commands.
 
+// ListPlaylists lists all stored playlists.
+func (cl *CommandList) ListPlaylists() *PromisedAttrsList {
+	return cl.attrsList("listplaylists", "playlist")
+}
+
+// PlaylistContents returns a list of attributes for songs in the specified
+// stored playlist.
+func (cl *CommandList) PlaylistContents(name string) *PromisedAttrsList {
+	return cl.attrsList("listplaylistinfo "+quote(name), "file")
+}
+
 // PlaylistLoad loads the specfied playlist into the current queue.
 // If start and end are non-negative, only songs in this range are loaded.
 func (cl *CommandList) PlaylistLoad(name string, start, end int) {
@@ -328,8 +850,122 @@ func (cl *CommandList) PlaylistSave(name string) {
 	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("save %s", quote(name))})
 }
 
+// Sticker related commands.
+
+// StickerDelete deletes sticker for the song with given URI.
+func (cl *CommandList) StickerDelete(uri string, name string) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("sticker delete song %s %s", quote(uri), quote(name))})
+}
+
+// StickerFind finds songs inside directory with URI which have a sticker
+// with given name. The URIs of the songs are returned by
+// PromisedStickers.URIs.
+func (cl *CommandList) StickerFind(uri string, name string) *PromisedStickers {
+	ps := &PromisedStickers{find: true}
+	cl.cmds = append(cl.cmds, command{promise: ps, cmd: fmt.Sprintf("sticker find song %s %s", quote(uri), quote(name))})
+	return ps
+}
+
+// StickerGet gets sticker value for the song with given URI.
+func (cl *CommandList) StickerGet(uri string, name string) *PromisedSticker {
+	var ps PromisedSticker
+	cl.cmds = append(cl.cmds, command{promise: &ps, cmd: fmt.Sprintf("sticker get song %s %s", quote(uri), quote(name))})
+	return &ps
+}
+
+// StickerList returns the stickers for the song with given URI.
+func (cl *CommandList) StickerList(uri string) *PromisedStickers {
+	var ps PromisedStickers
+	cl.cmds = append(cl.cmds, command{promise: &ps, cmd: fmt.Sprintf("sticker list song %s", quote(uri))})
+	return &ps
+}
+
+// StickerSet sets sticker value for the song with given URI.
+func (cl *CommandList) StickerSet(uri string, name string, value string) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("sticker set song %s %s %s", quote(uri), quote(name), quote(value))})
+}
+
+// AlbumArt retrieves the chunk at offset of the album artwork image for a
+// song with the given URI. Unlike Client.AlbumArt, it doesn't retrieve the
+// whole image: the remaining chunks must be requested again, at the offsets
+// up to the size returned by PromisedBinary.Value.
+func (cl *CommandList) AlbumArt(uri string, offset int) *PromisedBinary {
+	var pb PromisedBinary
+	cl.cmds = append(cl.cmds, command{promise: &pb, cmd: fmt.Sprintf("albumart %s %d", quote(uri), offset)})
+	return &pb
+}
+
+// ReadPicture retrieves the chunk at offset of the embedded album artwork
+// image for a song with the given URI, like AlbumArt.
+func (cl *CommandList) ReadPicture(uri string, offset int) *PromisedBinary {
+	var pb PromisedBinary
+	cl.cmds = append(cl.cmds, command{promise: &pb, cmd: fmt.Sprintf("readpicture %s %d", quote(uri), offset)})
+	return &pb
+}
+
+// Client to client commands.
+
+// Subscribe subscribes the client to channel.
+func (cl *CommandList) Subscribe(channel string) {
+	cl.cmds = append(cl.cmds, command{cmd: "subscribe " + quote(channel)})
+}
+
+// Unsubscribe unsubscribes the client from channel.
+func (cl *CommandList) Unsubscribe(channel string) {
+	cl.cmds = append(cl.cmds, command{cmd: "unsubscribe " + quote(channel)})
+}
+
+// Channels returns the channels with at least one subscribed client.
+func (cl *CommandList) Channels() *PromisedStrings {
+	return cl.strings("channels", "channel")
+}
+
+// ReadMessages returns the messages received on the channels the client is
+// subscribed to, with one set of "channel" and "message" attributes per
+// message.
+func (cl *CommandList) ReadMessages() *PromisedAttrsList {
+	return cl.attrsList("readmessages", "channel")
+}
+
+// SendMessage sends text to the clients subscribed to channel.
+func (cl *CommandList) SendMessage(channel, text string) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("sendmessage %s %s", quote(channel), quote(text))})
+}
+
+// Storage related commands.
+
+// Mount mounts the storage uri at path in the music directory.
+func (cl *CommandList) Mount(path, uri string) {
+	cl.cmds = append(cl.cmds, command{cmd: fmt.Sprintf("mount %s %s", quote(path), quote(uri))})
+}
+
+// Unmount unmounts the storage mounted at path.
+func (cl *CommandList) Unmount(path string) {
+	cl.cmds = append(cl.cmds, command{cmd: "unmount " + quote(path)})
+}
+
+// ListMounts returns the mounted storages, with one set of "mount" and
+// "storage" attributes per storage.
+func (cl *CommandList) ListMounts() *PromisedAttrsList {
+	return cl.attrsList("listmounts", "mount")
+}
+
+// ListNeighbors returns the storages found on the network, with one set of
+// "neighbor" and "name" attributes per storage.
+func (cl *CommandList) ListNeighbors() *PromisedAttrsList {
+	return cl.attrsList("listneighbors", "neighbor")
+}
+
 // End executes the command list.
 func (cl *CommandList) End() error {
+	retry := true
+	for i := range cl.cmds {
+		retry = retry && idempotent(cl.cmds[i].cmd)
+	}
+	return cl.client.run(retry, cl.end)
+}
+
+func (cl *CommandList) end() error {
 	// Tell MPD to start an OK command list:
 	beginID, beginErr := cl.client.cmd("command_list_ok_begin")
 	if beginErr != nil {
@@ -342,14 +978,13 @@ func (cl *CommandList) End() error {
 
 	// Issue all of the queued up commands in the list:
 	for i := range cmds {
-		cmdID, cmdErr := cl.client.cmd(cmds[i].cmd)
+		cmdID, cmdErr := cl.client.cmd("%v", cmds[i].cmd)
 		if cmdErr != nil {
 			return cmdErr
 		}
 		cl.client.text.StartResponse(cmdID)
 		cl.client.text.EndResponse(cmdID)
 	}
-
 	// Tell MPD to end the command list and do the operations.
 	endID, endErr := cl.client.cmd("command_list_end")
 	if endErr != nil {
@@ -360,30 +995,124 @@ func (cl *CommandList) End() error {
 
 	// Get the responses back and check for errors:
 	for i := range cmds {
-		switch p := cmds[i].promise.(type) {
-		case *PromisedAttrs:
-			a, aErr := cl.client.readAttrs("list_OK")
-			if aErr != nil {
-				return aErr
-			}
-			p.a = a
-		case *PromisedID:
-			a, aErr := cl.client.readAttrs("list_OK")
-			if aErr != nil {
-				return aErr
-			}
-			rid, ridErr := strconv.Atoi(a["Id"])
-			if ridErr != nil {
-				return ridErr
-			}
-			*p = PromisedID(rid)
-		default:
-			if err := cl.client.readOKLine("list_OK"); err != nil {
-				return err
-			}
+		if err := cl.readResponse(cmds[i].promise, "list_OK"); err != nil {
+			return withCommand(err, cmds[i].cmd)
 		}
 	}
 
 	// Finalize the command list with the last OK:
 	return cl.client.readOKLine("OK")
 }
+
+// readResponse reads the response of a command in the list, which ends
+// with terminator, and fulfills its promise.
+func (cl *CommandList) readResponse(promise interface{}, terminator string) error {
+	switch p := promise.(type) {
+	case *PromisedAttrs:
+		a, aErr := cl.client.readAttrs(terminator)
+		if aErr != nil {
+			return aErr
+		}
+		p.a = a
+	case *PromisedID:
+		a, aErr := cl.client.readAttrs(terminator)
+		if aErr != nil {
+			return aErr
+		}
+		rid, ridErr := strconv.Atoi(a["Id"])
+		if ridErr != nil {
+			return protocolError("can't parse Id", "Id: "+a["Id"])
+		}
+		*p = PromisedID(rid)
+	case *PromisedVolume:
+		a, err := cl.client.readAttrs(terminator)
+		if err != nil {
+			return err
+		}
+		if p.vol, err = parseVolume(a); err != nil {
+			return err
+		}
+		p.done = true
+	case *PromisedReplayGain:
+		a, err := cl.client.readAttrs(terminator)
+		if err != nil {
+			return err
+		}
+		mode, ok := a["replay_gain_mode"]
+		if !ok {
+			return protocolError("missing replay_gain_mode", "")
+		}
+		p.mode = ReplayGain(mode)
+	case *PromisedCount:
+		counts, err := cl.client.readCounts(terminator)
+		if err != nil {
+			return err
+		}
+		p.counts = counts
+	case *PromisedAttrsList:
+		list, err := p.read(terminator)
+		if err != nil {
+			return err
+		}
+		p.list = list
+	case *PromisedStrings:
+		list, err := p.read(terminator)
+		if err != nil {
+			return err
+		}
+		if list == nil {
+			list = []string{}
+		}
+		p.list = list
+	case *promisedSongs:
+		songs, err := cl.client.readSongs(terminator)
+		if err != nil {
+			return err
+		}
+		p.songs = songs
+	case *PromisedBinary:
+		data, size, err := cl.client.readBinary(terminator)
+		if err != nil {
+			return err
+		}
+		p.data, p.size = data, size
+	case *PromisedSticker:
+		a, err := cl.client.readAttrs(terminator)
+		if err != nil {
+			return err
+		}
+		s, err := parseStickerGet(a)
+		if err != nil {
+			return err
+		}
+		p.s = s
+	case *PromisedStickers:
+		if p.find {
+			attrs, err := cl.client.readAttrsList("file", terminator)
+			if err != nil {
+				return err
+			}
+			if p.uris, p.stickers, err = parseStickerFind(attrs); err != nil {
+				return err
+			}
+			return nil
+		}
+		attrs, err := cl.client.readAttrsList("sticker", terminator)
+		if err != nil {
+			return err
+		}
+		stickers, err := parseStickerList(attrs)
+		if err != nil {
+			return err
+		}
+		p.stickers = stickers
+	case onOK:
+		if err := cl.client.readOKLine(terminator); err != nil {
+			return err
+		}
+		p()
+	default:
+		return cl.client.readOKLine(terminator)
+	}
+	return nil
+}
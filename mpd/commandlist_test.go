@@ -5,6 +5,8 @@
 package mpd
 
 import (
+	"bytes"
+	"reflect"
 	"testing"
 )
 
@@ -73,3 +75,152 @@ func BenchmarkCommandList(b *testing.B) {
 	}
 	errSink = cl.End()
 }
+
+func TestCommandListCoverage(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	cl := cli.BeginCommandList()
+	playlistInfo, err := cl.PlaylistInfo(-1, -1)
+	if err != nil {
+		t.Fatalf("CommandList.PlaylistInfo failed: %s", err)
+	}
+	lists := map[string]struct {
+		promise *PromisedAttrsList
+		want    func() ([]Attrs, error)
+	}{
+		"PlaylistInfo":   {playlistInfo, func() ([]Attrs, error) { return cli.PlaylistInfo(-1, -1) }},
+		"ListAllInfo":    {cl.ListAllInfo("/"), func() ([]Attrs, error) { return cli.ListAllInfo("/") }},
+		"ListInfo":       {cl.ListInfo("foo"), func() ([]Attrs, error) { return cli.ListInfo("foo") }},
+		"Find":           {cl.Find("artist", "Artist 1"), func() ([]Attrs, error) { return cli.Find("artist", "Artist 1") }},
+		"ListGrouped":    {cl.ListGrouped(TagAlbum, Filter{}, TagArtist), func() ([]Attrs, error) { return cli.ListGrouped(TagAlbum, Filter{}, TagArtist) }},
+		"ListPlaylists":  {cl.ListPlaylists(), cli.ListPlaylists},
+		"ListOutputs":    {cl.ListOutputs(), cli.ListOutputs},
+		"ListPartitions": {cl.ListPartitions(), cli.ListPartitions},
+		"ListMounts":     {cl.ListMounts(), func() ([]Attrs, error) { return cli.Command("listmounts").AttrsList("mount") }},
+	}
+	strs := map[string]struct {
+		promise *PromisedStrings
+		want    func() ([]string, error)
+	}{
+		"GetFiles": {cl.GetFiles(), cli.GetFiles},
+		"List":     {cl.List("artist"), func() ([]string, error) { return cli.List("artist") }},
+		"ListWithFilter": {cl.ListWithFilter(TagAlbum, Eq(TagArtist, "Artist 3")), func() ([]string, error) {
+			return cli.ListWithFilter(TagAlbum, Eq(TagArtist, "Artist 3"))
+		}},
+		"TagTypes": {cl.TagTypes(), cli.TagTypes},
+	}
+	art := cl.AlbumArt("/file/with/huge-artwork", 3)
+	if err := cl.End(); err != nil {
+		t.Fatalf("CommandList.End failed: %s", err)
+	}
+
+	for name, tc := range lists {
+		got, err := tc.promise.Value()
+		if err != nil {
+			t.Errorf("%s: PromisedAttrsList.Value failed: %s", name, err)
+			continue
+		}
+		want, err := tc.want()
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s promised %v; want %v", name, got, want)
+		}
+	}
+	for name, tc := range strs {
+		got, err := tc.promise.Value()
+		if err != nil {
+			t.Errorf("%s: PromisedStrings.Value failed: %s", name, err)
+			continue
+		}
+		want, err := tc.want()
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s promised %v; want %v", name, got, want)
+		}
+	}
+	data, size, err := art.Value()
+	if err != nil {
+		t.Fatalf("PromisedBinary.Value failed: %s", err)
+	}
+	if !bytes.Equal(data, []byte{0x04, 0x05}) || size != 5 {
+		t.Errorf("AlbumArt promised %v of size %d; want [4 5] of size 5", data, size)
+	}
+}
+
+func TestCommandListStickers(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	const uri = "song0003.ogg"
+	cl := cli.BeginCommandList()
+	cl.StickerSet(uri, "cltest", "1=2")
+	get := cl.StickerGet(uri, "cltest")
+	list := cl.StickerList(uri)
+	find := cl.StickerFind("", "cltest")
+	cl.StickerDelete(uri, "cltest")
+	if err := cl.End(); err != nil {
+		t.Fatalf("CommandList.End failed: %s", err)
+	}
+
+	want := Sticker{Name: "cltest", Value: "1=2"}
+	if s, err := get.Value(); err != nil || *s != want {
+		t.Errorf("StickerGet promised %v, %v; want %v", s, err, want)
+	}
+	if _, err := list.URIs(); err == nil {
+		t.Errorf("StickerList promised URIs")
+	}
+	stks, err := list.Value()
+	if err != nil {
+		t.Fatalf("PromisedStickers.Value failed: %s", err)
+	}
+	found := false
+	for _, s := range stks {
+		found = found || s == want
+	}
+	if !found {
+		t.Errorf("StickerList promised %v; want it to include %v", stks, want)
+	}
+	uris, err := find.URIs()
+	if err != nil {
+		t.Fatalf("PromisedStickers.URIs failed: %s", err)
+	}
+	if stks, err := find.Value(); err != nil || !reflect.DeepEqual(uris, []string{uri}) || !reflect.DeepEqual(stks, []Sticker{want}) {
+		t.Errorf("StickerFind promised %v and %v, %v; want %v and %v", uris, stks, err, []string{uri}, []Sticker{want})
+	}
+}
+
+func TestCommandListConnectionState(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	cl := cli.BeginCommandList()
+	cl.NewPartition("commandlist")
+	cl.Partition("commandlist")
+	cl.TagTypesClear()
+	cl.TagTypesEnable("Artist")
+	status := cl.Status()
+	tags := cl.TagTypes()
+	cl.Partition("default")
+	cl.DelPartition("commandlist")
+	cl.TagTypesAll()
+	if err := cl.End(); err != nil {
+		t.Fatalf("CommandList.End failed: %s", err)
+	}
+	if a, err := status.Value(); err != nil || a["partition"] != "commandlist" {
+		t.Errorf("status in new partition is %v, %v", a, err)
+	}
+	if list, err := tags.Value(); err != nil || !reflect.DeepEqual(list, []string{"Artist"}) {
+		t.Errorf("TagTypes promised %v, %v; want [Artist]", list, err)
+	}
+	if cli.partition != "default" {
+		t.Errorf("partition is %q after switching to %q", cli.partition, "default")
+	}
+	if len(cli.tagTypes) != 1 || cli.tagTypes[0] != "tagtypes all" {
+		t.Errorf("recorded tag types commands are %q; want [tagtypes all]", cli.tagTypes)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CountResult is the number of songs in a group, as returned by Count.
+type CountResult struct {
+	// Group maps the tags the songs were grouped by to their values in
+	// this group. It's empty if the songs were not grouped.
+	Group map[string]string
+
+	Songs    int
+	Playtime time.Duration
+}
+
+// countArgs returns the quoted arguments of commands such as count.
+func countArgs(filter Filter, groupBy []Tag) string {
+	args := quote(filter.arg())
+	for _, tag := range groupBy {
+		args += " group " + quote(string(tag))
+	}
+	return args
+}
+
+// Count returns the number of songs matching filter and their total
+// playtime. If tags are given in groupBy, the songs are grouped by the
+// values of these tags, and a result is returned for each group, e.g. the
+// number of songs of each artist:
+//
+//	Count(Filter{}, TagArtist)
+//
+// MPD versions up to at least 0.24 only support grouping by a single tag.
+func (c *Client) Count(filter Filter, groupBy ...Tag) ([]CountResult, error) {
+	return c.Command("count %s", Quoted(countArgs(filter, groupBy))).counts()
+}
+
+// SearchCount is like Count, but the search is not case sensitive. It
+// requires MPD 0.24 or later.
+func (c *Client) SearchCount(filter Filter, groupBy ...Tag) ([]CountResult, error) {
+	return c.Command("searchcount %s", Quoted(countArgs(filter, groupBy))).counts()
+}
+
+func (cmd *Command) counts() (counts []CountResult, err error) {
+	err = cmd.exec(func() error {
+		counts, err = cmd.client.readCounts("OK")
+		return err
+	})
+	return
+}
+
+// readCounts reads the response to a count command.
+func (c *Client) readCounts(terminator string) ([]CountResult, error) {
+	counts := []CountResult{}
+	var cur *CountResult
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == terminator {
+			break
+		}
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			return nil, protocolError("can't parse line", line)
+		}
+		key, value := line[:i], line[i+2:]
+		// Group values come first, so a result ends with its playtime.
+		if cur == nil {
+			counts = append(counts, CountResult{Group: make(map[string]string)})
+			cur = &counts[len(counts)-1]
+		}
+		switch key {
+		case "songs":
+			cur.Songs, err = strconv.Atoi(value)
+		case "playtime":
+			cur.Playtime, err = parseSeconds(value)
+			cur = nil
+		default:
+			cur.Group[key] = value
+		}
+		if err != nil {
+			return nil, protocolError("can't parse "+key, line)
+		}
+	}
+	return counts, nil
+}
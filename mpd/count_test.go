@@ -0,0 +1,79 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCount(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	counts, err := cli.Count(Eq(TagArtist, "Guest"))
+	if err != nil {
+		t.Fatalf("Client.Count failed: %s", err)
+	}
+	want := []CountResult{{Group: map[string]string{}, Songs: 10, Playtime: 2255 * time.Second}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("Client.Count returned %+v; want %+v", counts, want)
+	}
+
+	counts, err = cli.Count(Eq(TagAlbum, "Album 0"), TagGenre)
+	if err != nil {
+		t.Fatalf("Client.Count failed: %s", err)
+	}
+	want = []CountResult{
+		{Group: map[string]string{"Genre": "Jazz"}, Songs: 5, Playtime: 927 * time.Second},
+		{Group: map[string]string{"Genre": "Pop"}, Songs: 4, Playtime: 740 * time.Second},
+		{Group: map[string]string{"Genre": "Rock"}, Songs: 5, Playtime: 922 * time.Second},
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("Client.Count returned %+v; want %+v", counts, want)
+	}
+
+	counts, err = cli.Count(Eq(TagArtist, "Nobody"), TagGenre)
+	if err != nil {
+		t.Fatalf("Client.Count failed: %s", err)
+	}
+	if len(counts) != 0 {
+		t.Errorf("Client.Count returned %+v; want no groups", counts)
+	}
+}
+
+func TestCountPromise(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	cl := cli.BeginCommandList()
+	pc := cl.SearchCount(Eq(TagArtist, "guest"))
+	pg := cl.Count(Filter{}, TagGenre)
+	if _, err := pc.Value(); err == nil {
+		t.Errorf("PromisedCount.Value succeeded before CommandList.End")
+	}
+	if err := cl.End(); err != nil {
+		t.Fatalf("CommandList.End failed: %s", err)
+	}
+	counts, err := pc.Value()
+	if err != nil {
+		t.Fatalf("PromisedCount.Value failed: %s", err)
+	}
+	if len(counts) != 1 || counts[0].Songs != 10 {
+		t.Errorf("SearchCount returned %+v; want 10 songs", counts)
+	}
+	groups, err := pg.Value()
+	if err != nil {
+		t.Fatalf("PromisedCount.Value failed: %s", err)
+	}
+	songs := 0
+	for _, g := range groups {
+		songs += g.Songs
+	}
+	if len(groups) != 3 || songs != 134 {
+		t.Errorf("Count grouped by genre returned %+v; want 3 groups of 134 songs in total", groups)
+	}
+}
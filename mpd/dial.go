@@ -0,0 +1,264 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A DialOption configures how DialWithOptions and DialContext connect to MPD.
+type DialOption func(*dialOptions)
+
+type dialOptions struct {
+	dialer          *net.Dialer
+	dialContext     func(ctx context.Context, network, addr string) (net.Conn, error)
+	dialTimeout     time.Duration
+	keepAlive       time.Duration
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	greetingTimeout time.Duration
+	password        string
+	partition       string
+
+	reconnectAttempts int
+	reconnectBackoff  time.Duration
+}
+
+// WithDialTimeout sets the maximum amount of time a dial will wait for the
+// connection to be established. By default there is no timeout.
+func WithDialTimeout(d time.Duration) DialOption {
+	return func(o *dialOptions) { o.dialTimeout = d }
+}
+
+// WithKeepAlive sets the interval between TCP keep-alive probes. A negative
+// value disables keep-alive. It has no effect when the connection is
+// established by a function given to WithDialContext.
+func WithKeepAlive(d time.Duration) DialOption {
+	return func(o *dialOptions) { o.keepAlive = d }
+}
+
+// WithReadTimeout sets the maximum amount of time to wait for data from MPD
+// while reading a response. The timeout is not a deadline for the whole
+// command: it applies to each read from the connection, so a long response,
+// or a SongIterator that is consumed slowly, doesn't time out as long as
+// MPD keeps sending data. It doesn't apply while a Watcher waits for
+// events. Use a context (see Client.WithContext) to bound the time spent
+// on a whole command. When the timeout
+// expires, the command fails and the connection is closed. By default
+// there is no timeout.
+func WithReadTimeout(d time.Duration) DialOption {
+	return func(o *dialOptions) { o.readTimeout = d }
+}
+
+// WithWriteTimeout sets the maximum amount of time to spend sending data to
+// MPD. Like the read timeout, it applies to each write to the connection
+// rather than to the whole command. When the timeout
+// expires, the command fails and the connection is closed. By default
+// there is no timeout.
+func WithWriteTimeout(d time.Duration) DialOption {
+	return func(o *dialOptions) { o.writeTimeout = d }
+}
+
+// WithGreetingTimeout sets the maximum amount of time to wait for the
+// greeting MPD sends once the connection is established. By default there
+// is no timeout.
+func WithGreetingTimeout(d time.Duration) DialOption {
+	return func(o *dialOptions) { o.greetingTimeout = d }
+}
+
+// WithPassword makes the client authenticate with MPD using the plaintext
+// password once connected. An empty password is ignored.
+func WithPassword(password string) DialOption {
+	return func(o *dialOptions) { o.password = password }
+}
+
+// WithPartition makes the client switch to the named partition once
+// connected.
+func WithPartition(name string) DialOption {
+	return func(o *dialOptions) { o.partition = name }
+}
+
+// WithDialer sets the dialer used to establish the connection.
+func WithDialer(d *net.Dialer) DialOption {
+	return func(o *dialOptions) { o.dialer = d }
+}
+
+// WithDialContext sets the function used to establish the connection. It
+// takes precedence over the dialer set by WithDialer, and can be used to
+// connect through proxies, tunnels or in-memory pipes.
+func WithDialContext(f func(ctx context.Context, network, addr string) (net.Conn, error)) DialOption {
+	return func(o *dialOptions) { o.dialContext = f }
+}
+
+func (o *dialOptions) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if o.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.dialTimeout)
+		defer cancel()
+	}
+	if o.dialContext != nil {
+		return o.dialContext(ctx, network, addr)
+	}
+	d := o.netDialer()
+	return d.DialContext(ctx, network, addr)
+}
+
+// netDialer returns the dialer set by WithDialer, or else a zero Dialer,
+// configured with the keep-alive set by WithKeepAlive.
+func (o *dialOptions) netDialer() net.Dialer {
+	var d net.Dialer
+	if o.dialer != nil {
+		d = *o.dialer
+	}
+	if o.keepAlive != 0 {
+		d.KeepAlive = o.keepAlive
+	}
+	return d
+}
+
+// DialWithOptions connects to MPD listening on address addr (e.g. "127.0.0.1:6600")
+// on network network (e.g. "tcp"), configured by opts.
+func DialWithOptions(network, addr string, opts ...DialOption) (*Client, error) {
+	return DialContext(context.Background(), network, addr, opts...)
+}
+
+// DialContext is like DialWithOptions, but ctx bounds the time spent
+// connecting, reading the greeting and authenticating. Once the Client is
+// returned, ctx has no effect on it.
+func DialContext(ctx context.Context, network, addr string, opts ...DialOption) (*Client, error) {
+	var o dialOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return dialContext(ctx, network, addr, o)
+}
+
+func dialContext(ctx context.Context, network, addr string, o dialOptions) (*Client, error) {
+	conn, err := o.dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if o.readTimeout > 0 || o.writeTimeout > 0 {
+		conn = &timeoutConn{Conn: conn, readTimeout: o.readTimeout, writeTimeout: o.writeTimeout}
+	}
+	c := &Client{clientConn: &clientConn{
+		sem:         make(chan struct{}, 1),
+		text:        textproto.NewConn(conn),
+		netConn:     conn,
+		network:     network,
+		addr:        addr,
+		dialOptions: o,
+	}}
+	if err := c.WithContext(ctx).greet(o.greetingTimeout); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if o.password != "" {
+		if err := c.WithContext(ctx).Command("password %s", o.password).OK(); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if o.partition != "" {
+		if err := c.WithContext(ctx).Partition(o.partition); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// greet reads the greeting sent by MPD once the connection is established.
+func (c *Client) greet(timeout time.Duration) error {
+	return c.run(false, func() error {
+		if timeout > 0 {
+			c.netConn.SetReadDeadline(time.Now().Add(timeout))
+			defer c.netConn.SetReadDeadline(time.Time{})
+		}
+		line, err := c.text.ReadLine()
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "OK MPD ") {
+			return protocolError("no greeting", line)
+		}
+		c.version = line[7:]
+		return nil
+	})
+}
+
+// timeoutConn is a connection whose reads and writes fail if they don't
+// make progress within a timeout. The timeouts don't apply while a deadline
+// set with SetDeadline, SetReadDeadline or SetWriteDeadline is in effect,
+// and the read timeout doesn't apply while it's suspended.
+type timeoutConn struct {
+	net.Conn
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
+	mu            sync.Mutex // guards the fields below and the deadlines of Conn
+	readDeadline  time.Time  // read deadline set explicitly
+	writeDeadline time.Time  // write deadline set explicitly
+	suspended     bool       // the read timeout is suspended
+}
+
+func (tc *timeoutConn) Read(b []byte) (int, error) {
+	tc.mu.Lock()
+	if tc.readTimeout > 0 && tc.readDeadline.IsZero() && !tc.suspended {
+		tc.Conn.SetReadDeadline(time.Now().Add(tc.readTimeout))
+	}
+	tc.mu.Unlock()
+	return tc.Conn.Read(b)
+}
+
+func (tc *timeoutConn) Write(b []byte) (int, error) {
+	tc.mu.Lock()
+	if tc.writeTimeout > 0 && tc.writeDeadline.IsZero() {
+		tc.Conn.SetWriteDeadline(time.Now().Add(tc.writeTimeout))
+	}
+	tc.mu.Unlock()
+	return tc.Conn.Write(b)
+}
+
+func (tc *timeoutConn) SetDeadline(t time.Time) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.readDeadline, tc.writeDeadline = t, t
+	return tc.Conn.SetDeadline(t)
+}
+
+func (tc *timeoutConn) SetReadDeadline(t time.Time) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.readDeadline = t
+	return tc.Conn.SetReadDeadline(t)
+}
+
+func (tc *timeoutConn) SetWriteDeadline(t time.Time) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.writeDeadline = t
+	return tc.Conn.SetWriteDeadline(t)
+}
+
+// suspendReadTimeout suspends the read timeout until the returned function
+// is called.
+func (tc *timeoutConn) suspendReadTimeout() (resume func()) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.suspended = true
+	// Clear the deadline of the previous read.
+	tc.Conn.SetReadDeadline(tc.readDeadline)
+	return func() {
+		tc.mu.Lock()
+		tc.suspended = false
+		tc.mu.Unlock()
+	}
+}
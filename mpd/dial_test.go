@@ -0,0 +1,189 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDialWithOptions(t *testing.T) {
+	dialed := false
+	var d net.Dialer
+	network, addr := localServer(t)
+	cli, err := DialWithOptions(network, addr,
+		WithDialTimeout(time.Second),
+		WithKeepAlive(time.Minute),
+		WithReadTimeout(time.Second),
+		WithWriteTimeout(time.Second),
+		WithGreetingTimeout(time.Second),
+		WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = true
+			return d.DialContext(ctx, network, addr)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("DialWithOptions(%q) = %v, %s want PTR, nil", addr, cli, err)
+	}
+	defer teardown(cli, t)
+	if !dialed {
+		t.Errorf("DialWithOptions did not use the dial function")
+	}
+	if err := cli.Ping(); err != nil {
+		t.Errorf("Client.Ping failed: %s", err)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	for _, tc := range []struct {
+		opts []DialOption
+		want time.Duration
+	}{
+		{nil, 0},
+		{[]DialOption{WithKeepAlive(time.Minute)}, time.Minute},
+		{[]DialOption{WithKeepAlive(-1)}, -1},
+		{[]DialOption{WithDialer(&net.Dialer{KeepAlive: time.Hour})}, time.Hour},
+		{[]DialOption{WithDialer(&net.Dialer{Timeout: time.Second, KeepAlive: time.Hour}), WithKeepAlive(time.Minute)}, time.Minute},
+	} {
+		var o dialOptions
+		for _, opt := range tc.opts {
+			opt(&o)
+		}
+		if d := o.netDialer(); d.KeepAlive != tc.want {
+			t.Errorf("dialer KeepAlive is %v; want %v", d.KeepAlive, tc.want)
+		}
+	}
+	d := &net.Dialer{Timeout: time.Second}
+	o := dialOptions{dialer: d, keepAlive: time.Minute}
+	if nd := o.netDialer(); nd.Timeout != time.Second {
+		t.Errorf("dialer Timeout is %v; want %v", nd.Timeout, time.Second)
+	}
+	if d.KeepAlive != 0 {
+		t.Errorf("WithKeepAlive modified the dialer given to WithDialer")
+	}
+}
+
+func TestDialPipe(t *testing.T) {
+	cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0", "OK\n")), WithReadTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+	}
+	defer cli.Close()
+	if v := cli.Version(); v != "0.23.0" {
+		t.Errorf("Client.Version = %q; want %q", v, "0.23.0")
+	}
+	if err := cli.Ping(); err != nil {
+		t.Fatalf("Client.Ping failed: %s", err)
+	}
+	err = cli.Ping()
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Fatalf("Client.Ping on stalled server = %v; want timeout", err)
+	}
+	if err := cli.Ping(); err != ErrClosed {
+		t.Fatalf("Client.Ping after timeout = %v; want %v", err, ErrClosed)
+	}
+}
+
+func TestDialGreeting(t *testing.T) {
+	for _, tc := range []struct {
+		name, greeting string
+	}{
+		{"no greeting", ""},
+		{"bad greeting", "HELLO"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial(tc.greeting)), WithGreetingTimeout(50*time.Millisecond))
+			if err == nil {
+				cli.Close()
+				t.Fatalf("DialWithOptions succeeded; want error")
+			}
+		})
+	}
+}
+
+func TestDialContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	network, addr := localAddr()
+	if cli, err := DialContext(ctx, network, addr); err == nil {
+		cli.Close()
+		t.Fatalf("DialContext with canceled context succeeded")
+	}
+}
+
+func TestReadTimeoutPerRead(t *testing.T) {
+	// The response is larger than the read buffer, so the songs are read
+	// from the connection as the iterator is advanced.
+	var reply strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&reply, "file: song%04d.ogg\nTitle: Title %d\n", i, i)
+	}
+	reply.WriteString("OK\n")
+	cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0", reply.String())), WithReadTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+	}
+	defer cli.Close()
+
+	it, err := cli.ListAllInfoIter("/")
+	if err != nil {
+		t.Fatalf("Client.ListAllInfoIter failed: %s", err)
+	}
+	defer it.Close()
+	n := 0
+	for it.Next() {
+		if n == 0 {
+			// A slow consumer doesn't make the iterator time out.
+			time.Sleep(100 * time.Millisecond)
+		}
+		n++
+	}
+	if err := it.Err(); err != nil || n != 500 {
+		t.Errorf("SongIterator returned %d songs and error %v; want 500 songs", n, err)
+	}
+}
+
+func TestReadTimeoutIdle(t *testing.T) {
+	cli := localDial(t, WithReadTimeout(50*time.Millisecond))
+	defer teardown(cli, t)
+	other := localDial(t)
+	defer other.Close()
+
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		other.Stop()
+	}()
+	changed, err := cli.idle("player")
+	if err != nil {
+		t.Fatalf("Client.idle failed: %s", err)
+	}
+	if len(changed) != 1 || changed[0] != "player" {
+		t.Errorf("Client.idle returned %q; want [player]", changed)
+	}
+	if err := cli.Ping(); err != nil {
+		t.Errorf("Client.Ping after idle failed: %s", err)
+	}
+}
+
+func TestDialAuthenticatedWrongPassword(t *testing.T) {
+	network, addr := localServer(t)
+	// The test server doesn't know the password command, so
+	// authentication fails.
+	cli, err := DialAuthenticated(network, addr, "secret")
+	if err == nil {
+		t.Fatalf("DialAuthenticated succeeded; want error")
+	}
+	if cli == nil {
+		t.Fatalf("DialAuthenticated returned a nil client")
+	}
+	defer cli.Close()
+	if err := cli.Ping(); err != nil {
+		t.Errorf("Client.Ping failed: %s", err)
+	}
+}
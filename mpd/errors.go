@@ -0,0 +1,103 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"errors"
+	"net/textproto"
+	"strconv"
+)
+
+// Errors matching the Error returned by MPD with the corresponding code,
+// e.g. errors.Is(err, ErrNoExist) reports whether err is an Error with code
+// ErrorNoExist.
+var (
+	ErrNotList       = errors.New("mpd: not a command list")
+	ErrArg           = errors.New("mpd: invalid argument")
+	ErrPassword      = errors.New("mpd: wrong password")
+	ErrPermission    = errors.New("mpd: permission denied")
+	ErrUnknown       = errors.New("mpd: unknown command")
+	ErrNoExist       = errors.New("mpd: no such object")
+	ErrPlaylistMax   = errors.New("mpd: playlist is too large")
+	ErrSystem        = errors.New("mpd: system error")
+	ErrPlaylistLoad  = errors.New("mpd: cannot load playlist")
+	ErrUpdateAlready = errors.New("mpd: already updating")
+	ErrPlayerSync    = errors.New("mpd: player not synchronized")
+	ErrExist         = errors.New("mpd: object already exists")
+)
+
+var codeErrors = map[ErrorCode]error{
+	ErrorNotList:       ErrNotList,
+	ErrorArg:           ErrArg,
+	ErrorPassword:      ErrPassword,
+	ErrorPermission:    ErrPermission,
+	ErrorUnknown:       ErrUnknown,
+	ErrorNoExist:       ErrNoExist,
+	ErrorPlaylistMax:   ErrPlaylistMax,
+	ErrorSystem:        ErrSystem,
+	ErrorPlaylistLoad:  ErrPlaylistLoad,
+	ErrorUpdateAlready: ErrUpdateAlready,
+	ErrorPlayerSync:    ErrPlayerSync,
+	ErrorExist:         ErrExist,
+}
+
+// Is reports whether target is the error matching the code of e, such as
+// ErrNoExist for ErrorNoExist. It is used by errors.Is.
+func (e Error) Is(target error) bool {
+	err, ok := codeErrors[e.Code]
+	return ok && err == target
+}
+
+// ProtocolError is returned when the response of MPD doesn't follow the
+// protocol, e.g. a line that can't be parsed.
+type ProtocolError struct {
+	Command string // command sent to MPD, if known
+	Line    string // offending line of the response, if any
+	Msg     string // description of the problem
+}
+
+func protocolError(msg, line string) *ProtocolError {
+	return &ProtocolError{Msg: msg, Line: line}
+}
+
+func (e *ProtocolError) Error() string {
+	s := e.Msg
+	if e.Line != "" {
+		s += ": " + e.Line
+	}
+	if e.Command != "" {
+		s += " (in response to " + strconv.Quote(e.Command) + ")"
+	}
+	return s
+}
+
+// Unwrap returns the error as a textproto.ProtocolError, which was returned
+// by previous versions of this package.
+func (e *ProtocolError) Unwrap() error {
+	if e.Line != "" {
+		return textproto.ProtocolError(e.Msg + ": " + e.Line)
+	}
+	return textproto.ProtocolError(e.Msg)
+}
+
+// withCommand records cmd as the command of err if it is a ProtocolError.
+func withCommand(err error, cmd string) error {
+	var pe *ProtocolError
+	if errors.As(err, &pe) && pe.Command == "" {
+		pe.Command = cmd
+	}
+	return err
+}
+
+// IsConnectionError reports whether err is caused by the connection to MPD,
+// e.g. a network error or a connection closed by MPD, rather than by a
+// command failed by MPD (Error) or an invalid response (ProtocolError).
+// The connection can't be used after such an error, unless the Client
+// reconnects automatically. ErrClosed is also a connection error, and so
+// are the errors of a context that interrupted a request, whether it was
+// canceled or its deadline expired.
+func IsConnectionError(err error) bool {
+	return errors.Is(err, ErrClosed) || isConnError(err)
+}
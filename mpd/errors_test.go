@@ -0,0 +1,241 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"testing"
+	"time"
+)
+
+func TestErrorIs(t *testing.T) {
+	for code, sentinel := range codeErrors {
+		err := fmt.Errorf("wrapped: %w", Error{Code: code, Message: "failed"})
+		if !errors.Is(err, sentinel) {
+			t.Errorf("error with code %d is not %v", code, sentinel)
+		}
+	}
+	if errors.Is(Error{Code: ErrorNoExist}, ErrExist) {
+		t.Errorf("error with code %d is %v", ErrorNoExist, ErrExist)
+	}
+	if errors.Is(Error{Code: 999}, ErrUnknown) {
+		t.Errorf("error with an unknown code is %v", ErrUnknown)
+	}
+
+	cli := localDial(t)
+	defer teardown(cli, t)
+	if err := cli.Partition("missing"); !errors.Is(err, ErrNoExist) {
+		t.Errorf("Client.Partition returned %v; want %v", err, ErrNoExist)
+	}
+	cl := cli.BeginCommandList()
+	cl.Ping()
+	cl.SetVolume(500)
+	if err := cl.End(); !errors.Is(err, ErrArg) {
+		t.Errorf("CommandList.End returned %v; want %v", err, ErrArg)
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0", "volume: 10\nbogus\nOK\n")))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+	}
+	defer cli.Close()
+
+	_, err = cli.Status()
+	var pe *ProtocolError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Client.Status returned %v; want a ProtocolError", err)
+	}
+	if pe.Command != "status" || pe.Line != "bogus" {
+		t.Errorf("ProtocolError has command %q and line %q; want %q and %q", pe.Command, pe.Line, "status", "bogus")
+	}
+	var tpe textproto.ProtocolError
+	if !errors.As(err, &tpe) {
+		t.Errorf("ProtocolError does not unwrap to a textproto.ProtocolError")
+	}
+	if IsConnectionError(err) {
+		t.Errorf("ProtocolError %v is a connection error", err)
+	}
+
+	_, err = DialWithOptions("pipe", "", WithDialContext(pipeDial("HELLO")))
+	if !errors.As(err, &pe) || pe.Line != "HELLO" {
+		t.Errorf("DialWithOptions with a wrong greeting returned %v; want a ProtocolError", err)
+	}
+}
+
+func TestTypedParseErrors(t *testing.T) {
+	var pe *ProtocolError
+	if _, err := parseVolume(Attrs{"volume": "loud"}); !errors.As(err, &pe) {
+		t.Errorf("parseVolume returned %v; want a ProtocolError", err)
+	}
+
+	cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0", "Id: x\nOK\n")))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+	}
+	defer cli.Close()
+	if _, err := cli.AddID("song0003.ogg", -1); !errors.As(err, &pe) || pe.Line != "Id: x" {
+		t.Errorf("Client.AddID returned %v; want a ProtocolError", err)
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, true},
+		{fmt.Errorf("reading: %w", io.ErrUnexpectedEOF), true},
+		{ErrClosed, true},
+		{context.Canceled, true},
+		{fmt.Errorf("waiting: %w", context.DeadlineExceeded), true},
+		{&net.OpError{Op: "read", Err: errors.New("connection reset")}, true},
+		{Error{Code: ErrorNoExist}, false},
+		{protocolError("can't parse line", "bogus"), false},
+	} {
+		if got := IsConnectionError(tc.err); got != tc.want {
+			t.Errorf("IsConnectionError(%v) = %v; want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestContextIsConnectionError(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		cancel func(context.Context) (context.Context, context.CancelFunc)
+		want   error
+	}{
+		{"Canceled", func(ctx context.Context) (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(ctx)
+			time.AfterFunc(50*time.Millisecond, cancel)
+			return ctx, cancel
+		}, context.Canceled},
+		{"DeadlineExceeded", func(ctx context.Context) (context.Context, context.CancelFunc) {
+			return context.WithTimeout(ctx, 50*time.Millisecond)
+		}, context.DeadlineExceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The server never answers, so the request is interrupted.
+			cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0")))
+			if err != nil {
+				t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+			}
+			defer cli.Close()
+
+			ctx, cancel := tc.cancel(context.Background())
+			defer cancel()
+			err = cli.WithContext(ctx).Ping()
+			if err != tc.want {
+				t.Fatalf("Client.Ping = %v; want %v", err, tc.want)
+			}
+			if !IsConnectionError(err) {
+				t.Errorf("IsConnectionError(%v) = false; want true", err)
+			}
+			if err := cli.Ping(); err != ErrClosed {
+				t.Errorf("Client.Ping after interrupted request = %v; want %v", err, ErrClosed)
+			}
+		})
+	}
+}
+
+// inject makes the test server write lines at the start of the response to
+// the next command of cli.
+func inject(t *testing.T, cli *Client, lines ...string) {
+	t.Helper()
+	if err := cli.Command("inject %s", Quoted(quoteArgs(lines))).OK(); err != nil {
+		t.Fatalf("inject failed: %s", err)
+	}
+}
+
+func TestResyncAfterProtocolError(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	for name, fn := range map[string]func() error{
+		"Status":       func() error { _, err := cli.Status(); return err },
+		"PlaylistInfo": func() error { _, err := cli.PlaylistInfo(-1, -1); return err },
+		"Find":         func() error { _, err := cli.Find("artist", "Artist 1"); return err },
+		"OK":           func() error { return cli.Random(false) },
+		"CommandList": func() error {
+			cl := cli.BeginCommandList()
+			cl.Ping()
+			cl.Status()
+			cl.CurrentSong()
+			return cl.End()
+		},
+		"SongIter": func() error {
+			it, err := cli.ListAllInfoIter("/")
+			if err != nil {
+				return err
+			}
+			return it.Close()
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			inject(t, cli, "bogus", "volume: 10")
+			var pe *ProtocolError
+			if err := fn(); !errors.As(err, &pe) {
+				t.Fatalf("got error %v; want a ProtocolError", err)
+			}
+			// The rest of the response was skipped.
+			if err := cli.Ping(); err != nil {
+				t.Fatalf("Client.Ping failed: %s", err)
+			}
+			status, err := cli.Status()
+			if err != nil {
+				t.Fatalf("Client.Status failed: %s", err)
+			}
+			if status["partition"] == "" {
+				t.Errorf("Client.Status returned %v", status)
+			}
+		})
+	}
+}
+
+func TestResyncAfterUpdate(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	for name, fn := range map[string]func() error{
+		"Update": func() error { _, err := cli.Update(""); return err },
+		"Rescan": func() error { _, err := cli.Rescan(""); return err },
+	} {
+		t.Run(name, func(t *testing.T) {
+			inject(t, cli, "updating_db: x")
+			var pe *ProtocolError
+			if err := fn(); !errors.As(err, &pe) {
+				t.Fatalf("got error %v; want a ProtocolError", err)
+			}
+			// The rest of the response was skipped.
+			if err := cli.Ping(); err != nil {
+				t.Fatalf("Client.Ping failed: %s", err)
+			}
+		})
+	}
+}
+
+func TestResyncFailure(t *testing.T) {
+	// The response ends without OK, so the connection can't be resynced.
+	cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0", "bogus\n")), WithReadTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+	}
+	defer cli.Close()
+
+	var pe *ProtocolError
+	if _, err := cli.Status(); !errors.As(err, &pe) {
+		t.Fatalf("Client.Status returned %v; want a ProtocolError", err)
+	}
+	if err := cli.Ping(); err != ErrClosed {
+		t.Errorf("Client.Ping after failed resync = %v; want %v", err, ErrClosed)
+	}
+}
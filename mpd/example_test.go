@@ -70,7 +70,7 @@ func ExampleNewWatcher() {
 	time.Sleep(3 * time.Minute)
 }
 
-func ExampleBeginCommandList() {
+func ExampleClient_BeginCommandList() {
 	// Connect to the MPD server.
 	conn, err := mpd.Dial("tcp", "localhost:6600")
 	if err != nil {
@@ -95,3 +95,35 @@ func ExampleBeginCommandList() {
 	}
 	log.Println("Currently playing file:", attrs["file"])
 }
+
+func ExampleParseStatus() {
+	conn, err := mpd.Dial("tcp", "localhost:6600")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer conn.Close()
+
+	// Fetch the status along with the current song.
+	cl := conn.BeginCommandList()
+	promisedStatus := cl.Status()
+	promisedSong := cl.CurrentSong()
+	if err := cl.End(); err != nil {
+		log.Fatalln("CommandList.End failed:", err)
+	}
+
+	attrs, err := promisedStatus.Value()
+	if err != nil {
+		log.Fatalln("PromisedAttrs.Value failed:", err)
+	}
+	status, err := mpd.ParseStatus(attrs)
+	if err != nil {
+		log.Fatalln("ParseStatus failed:", err)
+	}
+	song, err := promisedSong.Value()
+	if err != nil {
+		log.Fatalln("PromisedAttrs.Value failed:", err)
+	}
+	if status.State == mpd.StatePlay {
+		log.Printf("Playing %s (%v/%v)", song["file"], status.Elapsed, status.Duration)
+	}
+}
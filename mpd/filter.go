@@ -0,0 +1,260 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tag is the name of a song tag, as understood by MPD.
+type Tag string
+
+// Tags supported by MPD. See
+// https://mpd.readthedocs.io/en/latest/tags.html for their meaning.
+const (
+	TagArtist          Tag = "Artist"
+	TagArtistSort      Tag = "ArtistSort"
+	TagAlbum           Tag = "Album"
+	TagAlbumSort       Tag = "AlbumSort"
+	TagAlbumArtist     Tag = "AlbumArtist"
+	TagAlbumArtistSort Tag = "AlbumArtistSort"
+	TagTitle           Tag = "Title"
+	TagTitleSort       Tag = "TitleSort"
+	TagTrack           Tag = "Track"
+	TagName            Tag = "Name"
+	TagGenre           Tag = "Genre"
+	TagMood            Tag = "Mood"
+	TagDate            Tag = "Date"
+	TagOriginalDate    Tag = "OriginalDate"
+	TagComposer        Tag = "Composer"
+	TagComposerSort    Tag = "ComposerSort"
+	TagPerformer       Tag = "Performer"
+	TagConductor       Tag = "Conductor"
+	TagWork            Tag = "Work"
+	TagEnsemble        Tag = "Ensemble"
+	TagMovement        Tag = "Movement"
+	TagMovementNumber  Tag = "MovementNumber"
+	TagLocation        Tag = "Location"
+	TagGrouping        Tag = "Grouping"
+	TagComment         Tag = "Comment"
+	TagDisc            Tag = "Disc"
+	TagLabel           Tag = "Label"
+
+	TagMusicBrainzArtistID       Tag = "MUSICBRAINZ_ARTISTID"
+	TagMusicBrainzAlbumID        Tag = "MUSICBRAINZ_ALBUMID"
+	TagMusicBrainzAlbumArtistID  Tag = "MUSICBRAINZ_ALBUMARTISTID"
+	TagMusicBrainzTrackID        Tag = "MUSICBRAINZ_TRACKID"
+	TagMusicBrainzReleaseTrackID Tag = "MUSICBRAINZ_RELEASETRACKID"
+	TagMusicBrainzWorkID         Tag = "MUSICBRAINZ_WORKID"
+
+	// TagFile matches the URI of a song, relative to the music
+	// directory. It's not a real tag, but can be used in filters.
+	TagFile Tag = "file"
+
+	// TagAny matches any tag, and the URI of the song. It can only be
+	// used in filters.
+	TagAny Tag = "any"
+)
+
+// Filter is a filter expression selecting songs, understood by MPD 0.21 and
+// later. Filters are built with functions such as Eq and And:
+//
+//	f := And(Eq(TagArtist, "Artist Name"), Not(Eq(TagAlbum, "Album Name")))
+//
+// Filters are accepted by methods such as FindWithOptions, SearchWithOptions,
+// ListWithFilter, Count and FindAdd. The expression returned by String can
+// also be passed as a single argument to Find, Search or List, e.g.
+// Find(f.String()). Values are escaped within the expression, and the
+// expression itself is escaped when it's sent to MPD, so it must not be
+// quoted again by the caller.
+//
+// The zero value of Filter is the empty filter. Methods that take a Filter
+// treat it as matching all songs.
+type Filter struct {
+	expr string
+}
+
+// String returns the filter expression.
+func (f Filter) String() string {
+	return f.expr
+}
+
+// IsZero reports whether f is the empty filter.
+func (f Filter) IsZero() bool {
+	return f.expr == ""
+}
+
+// matchAll is the filter expression used for the empty filter, for commands
+// that require one.
+const matchAll = `(file != "")`
+
+// arg returns the filter expression to send to MPD.
+func (f Filter) arg() string {
+	if f.IsZero() {
+		return matchAll
+	}
+	return f.expr
+}
+
+func compare(tag Tag, op, value string) Filter {
+	return Filter{"(" + string(tag) + " " + op + " " + quote(value) + ")"}
+}
+
+// Eq returns a filter matching songs whose tag has exactly the given value.
+// Find matches case sensitively, Search case insensitively.
+func Eq(tag Tag, value string) Filter {
+	return compare(tag, "==", value)
+}
+
+// NotEq returns a filter matching songs whose tag doesn't have the given
+// value. Songs without the tag match if value is not empty.
+func NotEq(tag Tag, value string) Filter {
+	return compare(tag, "!=", value)
+}
+
+// Contains returns a filter matching songs whose tag contains value.
+func Contains(tag Tag, value string) Filter {
+	return compare(tag, "contains", value)
+}
+
+// StartsWith returns a filter matching songs whose tag starts with value.
+// It requires MPD 0.24 or later.
+func StartsWith(tag Tag, value string) Filter {
+	return compare(tag, "starts_with", value)
+}
+
+// Regex returns a filter matching songs whose tag matches the Perl
+// compatible regular expression re. MPD must be built with libpcre.
+func Regex(tag Tag, re string) Filter {
+	return compare(tag, "=~", re)
+}
+
+// Base returns a filter matching songs in the directory uri, relative to the
+// music directory, and its subdirectories.
+func Base(uri string) Filter {
+	return Filter{"(base " + quote(uri) + ")"}
+}
+
+// ModifiedSince returns a filter matching songs modified at t or later.
+func ModifiedSince(t time.Time) Filter {
+	return Filter{"(modified-since " + quote(t.UTC().Format(time.RFC3339)) + ")"}
+}
+
+// AudioFormat returns a filter matching songs with the audio format format,
+// written as "samplerate:bits:channels" (e.g. "44100:16:2"). Any part of
+// the format may be "*" to match all values, e.g. "*:24:*".
+func AudioFormat(format string) Filter {
+	op := "=="
+	if strings.Contains(format, "*") {
+		op = "=~"
+	}
+	return compare("AudioFormat", op, format)
+}
+
+// And returns a filter matching songs that match all of filters. Empty
+// filters are ignored.
+func And(filters ...Filter) Filter {
+	var exprs []string
+	for _, f := range filters {
+		if !f.IsZero() {
+			exprs = append(exprs, f.expr)
+		}
+	}
+	switch len(exprs) {
+	case 0:
+		return Filter{}
+	case 1:
+		return Filter{exprs[0]}
+	}
+	return Filter{"(" + strings.Join(exprs, " AND ") + ")"}
+}
+
+// Not returns a filter matching songs that don't match f. The negation of
+// the empty filter is the empty filter.
+func Not(f Filter) Filter {
+	if f.IsZero() {
+		return f
+	}
+	return Filter{"(!" + f.expr + ")"}
+}
+
+// FindOptions are options of commands that query the database, such as
+// FindWithOptions. The zero value selects all the matching songs, in
+// database order.
+type FindOptions struct {
+	// Sort is the tag to sort the songs by. Tag("Last-Modified") sorts
+	// by modification time.
+	Sort Tag
+
+	// Descending sorts the songs in descending order. It's ignored if
+	// Sort is empty.
+	Descending bool
+
+	// Window selects the range [Window[0], Window[1]) of the songs, e.g.
+	// a page of results. It's ignored unless Window[1] > Window[0].
+	Window [2]int
+}
+
+// findArgs returns the quoted arguments of commands such as find, selecting
+// the songs matching filter with options opts, which may be nil.
+func findArgs(filter Filter, opts *FindOptions) string {
+	args := quote(filter.arg())
+	if opts == nil {
+		return args
+	}
+	if opts.Sort != "" {
+		sort := string(opts.Sort)
+		if opts.Descending {
+			sort = "-" + sort
+		}
+		args += " sort " + quote(sort)
+	}
+	if opts.Window[1] > opts.Window[0] {
+		args += fmt.Sprintf(" window %d:%d", opts.Window[0], opts.Window[1])
+	}
+	return args
+}
+
+// FindWithOptions returns the songs matching filter, like Find, with options
+// opts. If opts is nil, it's the same as Find(filter.String()).
+func (c *Client) FindWithOptions(filter Filter, opts *FindOptions) ([]Attrs, error) {
+	return c.Command("find %s", Quoted(findArgs(filter, opts))).AttrsList("file")
+}
+
+// SearchWithOptions is like FindWithOptions, but the search is not case
+// sensitive.
+func (c *Client) SearchWithOptions(filter Filter, opts *FindOptions) ([]Attrs, error) {
+	return c.Command("search %s", Quoted(findArgs(filter, opts))).AttrsList("file")
+}
+
+// addArgs is like findArgs, for commands that add the songs to a playlist
+// at position pos. If pos is negative, the songs are appended.
+func addArgs(filter Filter, opts *FindOptions, pos int) string {
+	args := findArgs(filter, opts)
+	if pos >= 0 {
+		args += fmt.Sprintf(" position %d", pos)
+	}
+	return args
+}
+
+// FindAdd adds the songs matching filter to the queue, at position pos.
+// Options opts, which may be nil, select the songs as in FindWithOptions.
+// If pos is negative, the songs are added to the end of the queue.
+func (c *Client) FindAdd(filter Filter, opts *FindOptions, pos int) error {
+	return c.Command("findadd %s", Quoted(addArgs(filter, opts, pos))).OK()
+}
+
+// SearchAdd is like FindAdd, but the search is not case sensitive.
+func (c *Client) SearchAdd(filter Filter, opts *FindOptions, pos int) error {
+	return c.Command("searchadd %s", Quoted(addArgs(filter, opts, pos))).OK()
+}
+
+// SearchAddPl is like SearchAdd, but adds the songs to the stored playlist
+// name, which is created if it doesn't exist.
+func (c *Client) SearchAddPl(name string, filter Filter, opts *FindOptions, pos int) error {
+	return c.Command("searchaddpl %s %s", name, Quoted(addArgs(filter, opts, pos))).OK()
+}
@@ -0,0 +1,259 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterString(t *testing.T) {
+	for _, tc := range []struct {
+		f    Filter
+		want string
+	}{
+		{Filter{}, ``},
+		{Eq(TagArtist, "x"), `(Artist == "x")`},
+		{NotEq(TagAlbum, "y"), `(Album != "y")`},
+		{Contains(TagAny, "z"), `(any contains "z")`},
+		{StartsWith(TagTitle, "The"), `(Title starts_with "The")`},
+		{Regex(TagFile, `\.flac$`), `(file =~ "\\.flac$")`},
+		{Base("music/rock"), `(base "music/rock")`},
+		{ModifiedSince(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), `(modified-since "2020-01-02T03:04:05Z")`},
+		{AudioFormat("44100:16:2"), `(AudioFormat == "44100:16:2")`},
+		{AudioFormat("*:24:*"), `(AudioFormat =~ "*:24:*")`},
+		{Eq(TagTitle, `It's "Quoted" \o/`), `(Title == "It\'s \"Quoted\" \\o/")`},
+		{And(Eq(TagArtist, "x"), NotEq(TagAlbum, "y")), `((Artist == "x") AND (Album != "y"))`},
+		{And(Filter{}, Eq(TagArtist, "x")), `(Artist == "x")`},
+		{And(), ``},
+		{Not(Eq(TagArtist, "x")), `(!(Artist == "x"))`},
+		{Not(Filter{}), ``},
+	} {
+		if got := tc.f.String(); got != tc.want {
+			t.Errorf("filter is %s; want %s", got, tc.want)
+		}
+	}
+}
+
+func TestFilterCommand(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	// The filter is escaped once more when it's sent to MPD.
+	f := Eq(TagArtist, `"x"`)
+	want := `find "(Artist == \"\\\"x\\\"\")"`
+	if got := cli.Command("find %s", f.String()).String(); got != want {
+		t.Errorf("command is %s; want %s", got, want)
+	}
+}
+
+func TestFindFilter(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	for _, tc := range []struct {
+		f    Filter
+		want int
+	}{
+		{Eq(TagArtist, "Guest"), 10},
+		{And(Eq(TagArtist, "Guest"), Not(Eq(TagGenre, "Pop"))), 6},
+		{And(Eq(TagGenre, "Jazz"), NotEq(TagArtist, "Artist 1")), 40},
+		{Contains(TagTitle, "Title 9"), 11},
+		{StartsWith(TagAlbum, "Album 1"), 10},
+		{Regex(TagFile, `^song00[0-4]`), 50},
+		{ModifiedSince(time.Date(2014, 7, 2, 13, 0, 0, 0, time.UTC)), 72},
+		{AudioFormat("44100:*:2"), 100},
+		{Eq(TagTitle, `It's "Quoted" \o/`), 0},
+	} {
+		attrs, err := cli.Find(tc.f.String())
+		if err != nil {
+			t.Errorf("Client.Find(%s) failed: %s", tc.f, err)
+			continue
+		}
+		if len(attrs) != tc.want {
+			t.Errorf("Client.Find(%s) returned %d songs; want %d", tc.f, len(attrs), tc.want)
+		}
+	}
+
+	attrs, err := cli.Search(Eq(TagArtist, "guest").String())
+	if err != nil {
+		t.Fatalf("Client.Search failed: %s", err)
+	}
+	if len(attrs) != 10 {
+		t.Errorf("Client.Search returned %d songs; want 10", len(attrs))
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	albums, err := cli.List(string(TagAlbum), Eq(TagArtist, "Artist 3").String())
+	if err != nil {
+		t.Fatalf("Client.List failed: %s", err)
+	}
+	if len(albums) != 10 || albums[0] != "Album 0" {
+		t.Errorf("Client.List returned %q; want 10 albums starting with %q", albums, "Album 0")
+	}
+
+	filtered, err := cli.ListWithFilter(TagAlbum, Eq(TagArtist, "Artist 3"))
+	if err != nil {
+		t.Fatalf("Client.ListWithFilter failed: %s", err)
+	}
+	if !reflect.DeepEqual(filtered, albums) {
+		t.Errorf("Client.ListWithFilter returned %q; want %q", filtered, albums)
+	}
+	all, err := cli.ListWithFilter(TagAlbum, Filter{})
+	if err != nil {
+		t.Fatalf("Client.ListWithFilter failed: %s", err)
+	}
+	if len(all) != 10 {
+		t.Errorf("Client.ListWithFilter with the empty filter returned %q; want 10 albums", all)
+	}
+}
+
+func TestFindWithOptions(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	for _, tc := range []struct {
+		filter Filter
+		opts   *FindOptions
+		want   []string
+	}{
+		{
+			filter: Eq(TagArtist, "Guest"),
+			opts:   &FindOptions{Sort: TagTitle, Descending: true, Window: [2]int{1, 3}},
+			want:   []string{"Title 80", "Title 70"},
+		},
+		{
+			filter: Eq(TagArtist, "guest"),
+			opts:   &FindOptions{Sort: TagTitle, Window: [2]int{0, 2}},
+			want:   []string{},
+		},
+		{
+			filter: Filter{},
+			opts:   &FindOptions{Sort: "Last-Modified", Descending: true, Window: [2]int{0, 1}},
+			want:   []string{"Title 99"},
+		},
+		{
+			filter: Eq(TagAlbum, "Album 2"),
+			opts:   &FindOptions{Window: [2]int{5, 5}},
+			want:   []string{"Title 20", "Title 21", "Title 22", "Title 23", "Title 24", "Title 25", "Title 26", "Title 27", "Title 28", "Title 29"},
+		},
+		{
+			filter: Eq(TagAlbum, "Album 2"),
+			opts:   &FindOptions{Window: [2]int{8, 20}},
+			want:   []string{"Title 28", "Title 29"},
+		},
+	} {
+		attrs, err := cli.FindWithOptions(tc.filter, tc.opts)
+		if err != nil {
+			t.Errorf("Client.FindWithOptions(%s, %+v) failed: %s", tc.filter, tc.opts, err)
+			continue
+		}
+		titles := []string{}
+		for _, a := range attrs {
+			titles = append(titles, a["Title"])
+		}
+		if !reflect.DeepEqual(titles, tc.want) {
+			t.Errorf("Client.FindWithOptions(%s, %+v) returned %q; want %q", tc.filter, tc.opts, titles, tc.want)
+		}
+	}
+
+	attrs, err := cli.SearchWithOptions(Eq(TagArtist, "guest"), &FindOptions{Sort: TagTitle, Window: [2]int{0, 2}})
+	if err != nil {
+		t.Fatalf("Client.SearchWithOptions failed: %s", err)
+	}
+	if len(attrs) != 2 || attrs[0]["Title"] != "Title 0" || attrs[1]["Title"] != "Title 10" {
+		t.Errorf("Client.SearchWithOptions returned %v; want Title 0 and Title 10", attrs)
+	}
+}
+
+func TestFindAdd(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	if err := cli.Clear(); err != nil {
+		t.Fatalf("Client.Clear failed: %s", err)
+	}
+	opts := &FindOptions{Sort: TagTitle, Descending: true, Window: [2]int{0, 2}}
+	if err := cli.FindAdd(Eq(TagAlbum, "Album 1"), opts, -1); err != nil {
+		t.Fatalf("Client.FindAdd failed: %s", err)
+	}
+	if err := cli.FindAdd(Eq(TagTitle, "Title 5"), nil, 1); err != nil {
+		t.Fatalf("Client.FindAdd failed: %s", err)
+	}
+	queue, err := cli.PlaylistInfo(-1, -1)
+	if err != nil {
+		t.Fatalf("Client.PlaylistInfo failed: %s", err)
+	}
+	var files []string
+	for _, a := range queue {
+		files = append(files, a["file"])
+	}
+	want := []string{"song0019.ogg", "song0005.ogg", "song0018.ogg"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("queue is %q; want %q", files, want)
+	}
+
+	if err := cli.SearchAddPl("searchaddpl", Eq(TagArtist, "guest"), &FindOptions{Window: [2]int{0, 3}}, -1); err != nil {
+		t.Fatalf("Client.SearchAddPl failed: %s", err)
+	}
+	defer cli.PlaylistRemove("searchaddpl")
+	pl, err := cli.PlaylistContents("searchaddpl")
+	if err != nil {
+		t.Fatalf("Client.PlaylistContents failed: %s", err)
+	}
+	if len(pl) != 3 {
+		t.Errorf("stored playlist has %d songs; want 3", len(pl))
+	}
+
+	cl := cli.BeginCommandList()
+	cl.Clear()
+	cl.SearchAdd(Eq(TagArtist, "GUEST"), nil, -1)
+	cl.FindAdd(Eq(TagArtist, "GUEST"), nil, 0)
+	cl.SearchAddPl("searchaddpl", Eq(TagTitle, "title 1"), nil, 0)
+	if err := cl.End(); err != nil {
+		t.Fatalf("CommandList.End failed: %s", err)
+	}
+	if queue, err = cli.PlaylistInfo(-1, -1); err != nil || len(queue) != 10 {
+		t.Errorf("queue has %d songs (%v); want 10", len(queue), err)
+	}
+	if pl, err = cli.PlaylistContents("searchaddpl"); err != nil || len(pl) != 4 {
+		t.Errorf("stored playlist has %d songs (%v); want 4", len(pl), err)
+	}
+}
+
+func TestListGrouped(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	records, err := cli.ListGrouped(TagGenre, Eq(TagAlbum, "Album 3"), TagAlbum, TagArtist)
+	if err != nil {
+		t.Fatalf("Client.ListGrouped failed: %s", err)
+	}
+	if len(records) != 16 {
+		t.Fatalf("Client.ListGrouped returned %d records; want 16", len(records))
+	}
+	for i, want := range []Attrs{
+		{"Album": "Album 3", "Artist": "Artist 0", "Genre": "Pop"},
+		{"Album": "Album 3", "Artist": "Artist 0", "Genre": "Rock"},
+		{"Album": "Album 3", "Artist": "Artist 1", "Genre": "Jazz"},
+	} {
+		if !attrsEqual(records[i], want) {
+			t.Errorf("record %d is %v; want %v", i, records[i], want)
+		}
+	}
+
+	records, err = cli.ListGrouped(TagAlbum, Filter{})
+	if err != nil {
+		t.Fatalf("Client.ListGrouped failed: %s", err)
+	}
+	if len(records) != 10 || !attrsEqual(records[0], Attrs{"Album": "Album 0"}) {
+		t.Errorf("Client.ListGrouped returned %v; want 10 albums", records)
+	}
+}
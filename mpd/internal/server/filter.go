@@ -0,0 +1,222 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// filter reports whether the song sg matches a filter expression. If fold
+// is true, values are compared case insensitively.
+type filter func(sg *song, fold bool) bool
+
+// filterParser parses the filter expressions of MPD 0.21 and later.
+type filterParser struct {
+	s string
+	i int
+}
+
+var errBadFilter = errors.New("malformed filter expression")
+
+// parseFilter parses the filter expression expr.
+func parseFilter(expr string) (filter, error) {
+	p := &filterParser{s: expr}
+	f, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.i != len(p.s) {
+		return nil, errBadFilter
+	}
+	return f, nil
+}
+
+func (p *filterParser) skipSpace() {
+	for p.i < len(p.s) && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *filterParser) consume(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.i:], tok) {
+		p.i += len(tok)
+		return true
+	}
+	return false
+}
+
+// word reads a tag name or an operator.
+func (p *filterParser) word() string {
+	p.skipSpace()
+	start := p.i
+	for p.i < len(p.s) && p.s[p.i] != ' ' && p.s[p.i] != ')' && p.s[p.i] != '"' && p.s[p.i] != '\'' {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// value reads a quoted value.
+func (p *filterParser) value() (string, error) {
+	p.skipSpace()
+	if p.i >= len(p.s) || p.s[p.i] != '"' && p.s[p.i] != '\'' {
+		return "", errBadFilter
+	}
+	q := p.s[p.i]
+	p.i++
+	var v []byte
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == q:
+			return string(v), nil
+		case c == '\\' && p.i < len(p.s):
+			c = p.s[p.i]
+			p.i++
+		}
+		v = append(v, c)
+	}
+	return "", errBadFilter
+}
+
+func (p *filterParser) expr() (filter, error) {
+	if !p.consume("(") {
+		return nil, errBadFilter
+	}
+	var f filter
+	var err error
+	p.skipSpace()
+	switch {
+	case p.consume("!"):
+		g, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		f = func(sg *song, fold bool) bool { return !g(sg, fold) }
+	case p.i < len(p.s) && p.s[p.i] == '(':
+		f, err = p.and()
+	default:
+		f, err = p.compare()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !p.consume(")") {
+		return nil, errBadFilter
+	}
+	return f, nil
+}
+
+func (p *filterParser) and() (filter, error) {
+	var filters []filter
+	for {
+		f, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+		if !p.consume("AND") {
+			break
+		}
+	}
+	return func(sg *song, fold bool) bool {
+		for _, f := range filters {
+			if !f(sg, fold) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (p *filterParser) compare() (filter, error) {
+	name := p.word()
+	switch name {
+	case "base":
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return func(sg *song, fold bool) bool {
+			return strings.HasPrefix(sg.file, strings.TrimSuffix(v, "/")+"/")
+		}, nil
+	case "modified-since":
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errBadFilter
+		}
+		return func(sg *song, fold bool) bool {
+			return !sg.lastModified.Before(t)
+		}, nil
+	}
+	op := p.word()
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	var match func(s string, fold bool) bool
+	switch {
+	case name == "AudioFormat" && op == "=~":
+		match = func(s string, fold bool) bool { return matchAudioFormat(s, v) }
+	case op == "==" || op == "!=":
+		match = func(s string, fold bool) bool {
+			return s == v || fold && strings.EqualFold(s, v)
+		}
+	case op == "contains":
+		match = func(s string, fold bool) bool {
+			if fold {
+				return strings.Contains(strings.ToLower(s), strings.ToLower(v))
+			}
+			return strings.Contains(s, v)
+		}
+	case op == "starts_with":
+		match = func(s string, fold bool) bool {
+			if fold {
+				return strings.HasPrefix(strings.ToLower(s), strings.ToLower(v))
+			}
+			return strings.HasPrefix(s, v)
+		}
+	case op == "=~" || op == "!~":
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, errBadFilter
+		}
+		match = func(s string, fold bool) bool { return re.MatchString(s) }
+	default:
+		return nil, errBadFilter
+	}
+	negate := op == "!=" || op == "!~"
+	return func(sg *song, fold bool) bool {
+		for _, s := range sg.values(name) {
+			if match(s, fold) {
+				return !negate
+			}
+		}
+		return negate
+	}, nil
+}
+
+// matchAudioFormat reports whether the audio format s matches mask, in
+// which any part may be "*".
+func matchAudioFormat(s, mask string) bool {
+	sp, mp := strings.Split(s, ":"), strings.Split(mask, ":")
+	if len(sp) != len(mp) {
+		return false
+	}
+	for i := range sp {
+		if mp[i] != "*" && mp[i] != sp[i] {
+			return false
+		}
+	}
+	return true
+}
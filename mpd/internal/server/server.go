@@ -15,13 +15,96 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
-// attrs is a set of attributes returned by MPD.
-type attrs map[string]string
+// tag is a tag of a song. A song may have several tags with the same name.
+type tag struct {
+	name, value string
+}
+
+// song is a song in the database.
+type song struct {
+	file         string
+	lastModified time.Time
+	duration     float64 // in seconds
+	tags         []tag
+	removed      bool // removed from the database with dbtest
+}
+
+func newSong(i int) *song {
+	sg := &song{
+		file:         fmt.Sprintf("song%04d.ogg", i),
+		lastModified: time.Date(2014, 7, 2, 12, 32, 26, 0, time.UTC).Add(time.Duration(i) * time.Minute),
+		duration:     180 + float64(i) + 0.5,
+	}
+	sg.tags = append(sg.tags, tag{"Artist", fmt.Sprintf("Artist %d", i%10)})
+	if i%10 == 0 {
+		sg.tags = append(sg.tags, tag{"Artist", "Guest"})
+	}
+	sg.tags = append(sg.tags,
+		tag{"Album", fmt.Sprintf("Album %d", i/10)},
+		tag{"Title", fmt.Sprintf("Title %d", i)},
+		tag{"Track", strconv.Itoa(i%10 + 1)},
+	)
+	if i%2 == 0 {
+		sg.tags = append(sg.tags, tag{"Genre", "Rock"})
+	} else {
+		sg.tags = append(sg.tags, tag{"Genre", "Jazz"})
+	}
+	if i%3 == 0 {
+		sg.tags = append(sg.tags, tag{"Genre", "Pop"})
+	}
+	return sg
+}
+
+// values returns the values of tag name. The "file", "any" and
+// "AudioFormat" pseudo tags are also supported.
+func (sg *song) values(name string) []string {
+	var v []string
+	if name == "AudioFormat" {
+		return []string{songFormat}
+	}
+	if strings.EqualFold(name, "file") || strings.EqualFold(name, "any") {
+		v = append(v, sg.file)
+	}
+	for _, t := range sg.tags {
+		if strings.EqualFold(name, "any") || strings.EqualFold(name, t.name) {
+			v = append(v, t.value)
+		}
+	}
+	return v
+}
+
+// writeQueueEntry writes the song at position i in the queue.
+func (s *server) writeQueueEntry(p *textproto.Conn, i int) {
+	writeSong(p, s.database[s.currentPlaylist.At(i)])
+	p.PrintfLine("Pos: %d", i)
+	p.PrintfLine("Id: %d", s.currentPlaylist.songs[i].id)
+	if prio := s.currentPlaylist.songs[i].prio; prio > 0 {
+		p.PrintfLine("Prio: %d", prio)
+	}
+}
+
+// songFormat is the audio format of all the songs in the database.
+const songFormat = "44100:16:2"
+
+func writeSong(p *textproto.Conn, sg *song) {
+	p.PrintfLine("file: %s", sg.file)
+	p.PrintfLine("Last-Modified: %s", sg.lastModified.Format(time.RFC3339))
+	p.PrintfLine("Format: %s", songFormat)
+	for _, t := range sg.tags {
+		p.PrintfLine("%s: %s", t.name, t.value)
+	}
+	p.PrintfLine("Time: %d", int(sg.duration+0.5))
+	p.PrintfLine("duration: %.3f", sg.duration)
+}
 
 const (
+	accErrorArg     = 2
 	accErrorNoExist = 50
+	accErrorExist   = 56
 )
 
 func unquote(line string, start int) (string, int) {
@@ -66,13 +149,16 @@ func parseArgs(line string) (args []string) {
 }
 
 type playlistEntry struct {
-	song int
-	id   int
+	song    int
+	id      int
+	prio    int
+	version int // version of the playlist when the entry last changed
 }
 
 type playlist struct {
-	songs []playlistEntry
-	maxid int
+	songs   []playlistEntry
+	maxid   int
+	version int
 }
 
 func newPlaylist() *playlist {
@@ -87,10 +173,30 @@ func (p *playlist) Len() int {
 	return len(p.songs)
 }
 
+// changed increments the version of the playlist, and marks the entries
+// from position i on as changed.
+func (p *playlist) changed(i int) {
+	p.version++
+	for ; i < len(p.songs); i++ {
+		p.songs[i].version = p.version
+	}
+}
+
 func (p *playlist) Add(song int) int {
+	return p.Insert(len(p.songs), song)
+}
+
+// Insert inserts song at position i, or appends it if i is out of range.
+func (p *playlist) Insert(i, song int) int {
 	entry := playlistEntry{song: song, id: p.maxid}
-	p.songs = append(p.songs, entry)
 	p.maxid++
+	if i < 0 || i > len(p.songs) {
+		i = len(p.songs)
+	}
+	p.songs = append(p.songs, entry)
+	copy(p.songs[i+1:], p.songs[i:])
+	p.songs[i] = entry
+	p.changed(i)
 	return entry.id
 }
 
@@ -100,10 +206,41 @@ func (p *playlist) Delete(i int) {
 	}
 	copy(p.songs[i:], p.songs[i+1:])
 	p.songs = p.songs[:len(p.songs)-1]
+	p.changed(i)
+}
+
+// Move moves the entries in positions [start, end) to position to in the
+// resulting playlist.
+func (p *playlist) Move(start, end, to int) {
+	moved := append([]playlistEntry(nil), p.songs[start:end]...)
+	rest := append(append([]playlistEntry(nil), p.songs[:start]...), p.songs[end:]...)
+	p.songs = append(append(append(p.songs[:0], rest[:to]...), moved...), rest[to:]...)
+	if to < start {
+		start = to
+	}
+	p.changed(start)
+}
+
+// SetPrio sets the priority of the entry at position i.
+func (p *playlist) SetPrio(i, prio int) {
+	p.songs[i].prio = prio
+	p.version++
+	p.songs[i].version = p.version
 }
 
 func (p *playlist) Clear() {
 	p.songs = p.songs[:0]
+	p.changed(0)
+}
+
+// Index returns the position of the entry with identifier id, or -1.
+func (p *playlist) Index(id int) int {
+	for i, e := range p.songs {
+		if e.id == id {
+			return i
+		}
+	}
+	return -1
 }
 
 func (p *playlist) Append(q *playlist) {
@@ -161,38 +298,145 @@ func (ss stickers) Sorted() []*sticker {
 	return v
 }
 
+// connState is the state of a client connection.
+type connState struct {
+	partition string
+	tagTypes  []string
+	events    *eventQueue
+
+	channels map[string]bool // subscribed channels
+	messages []message       // messages not read yet
+
+	inject []string // lines written at the start of the next response
+}
+
+// output is an audio output.
+type output struct {
+	name       string
+	plugin     string
+	enabled    string
+	attributes []tag // runtime attributes
+}
+
+// attribute returns the runtime attribute name, or nil if the output has
+// no such attribute.
+func (o *output) attribute(name string) *tag {
+	for i := range o.attributes {
+		if o.attributes[i].name == name {
+			return &o.attributes[i]
+		}
+	}
+	return nil
+}
+
+func newOutputs() []*output {
+	return []*output{{
+		name:    "downstairs",
+		plugin:  "alsa",
+		enabled: "1",
+		attributes: []tag{
+			{"allowed_formats", ""},
+			{"dop", "0"},
+		},
+	}, {
+		name:    "upstairs",
+		plugin:  "pulse",
+		enabled: "0",
+	}}
+}
+
+// message is a message sent to a channel with sendmessage.
+type message struct {
+	channel, text string
+}
+
+func newConnState() *connState {
+	return &connState{
+		partition: "default",
+		tagTypes:  append([]string(nil), knownTagTypes...),
+		events:    newEventQueue(),
+		channels:  make(map[string]bool),
+	}
+}
+
+var knownTagTypes = []string{
+	"Artist",
+	"Album",
+	"AlbumArtist",
+	"Title",
+	"Track",
+	"Genre",
+	"Date",
+}
+
+func indexTagType(v []string, name string) int {
+	for i, s := range v {
+		if strings.EqualFold(s, name) {
+			return i
+		}
+	}
+	return -1
+}
+
 type server struct {
+	mu sync.Mutex // guards the state below, shared by all connections
+
 	state           string
-	database        []attrs        // database of songs
+	options         map[string]string // playback options, e.g. repeat
+	volume          int
+	database        []*song        // database of songs
 	index           map[string]int // maps URI to database index
 	playlists       map[string]*playlist
+	partitions      map[string]bool
+	mounts          map[string]string // maps mount path to storage URI
+	outputs         []*output
 	currentPlaylist *playlist
 	songStickers    map[string]stickers
 	pos             int // in currentPlaylist
 	artwork         []byte
-	idleEventc      chan string
-	idleStartc      chan *idleRequest
-	idleEndc        chan uint
+	idleEventc      chan idleEvent
+	conns           map[*connState]bool // connections, e.g. to deliver messages
+
+	queuesMu sync.Mutex
+	queues   map[*eventQueue]bool // event queues of the connections
+}
+
+// defaultOptions returns the playback options of a new server.
+func defaultOptions() map[string]string {
+	return map[string]string{
+		"repeat":           "0",
+		"random":           "0",
+		"single":           "0",
+		"consume":          "0",
+		"xfade":            "0",
+		"mixrampdb":        "0",
+		"mixrampdelay":     "nan",
+		"replay_gain_mode": "off",
+	}
 }
 
 func newServer() *server {
 	s := &server{
 		state:           "stop",
-		database:        make([]attrs, 100),
+		options:         defaultOptions(),
+		volume:          50,
+		database:        make([]*song, 100),
 		index:           make(map[string]int, 100),
 		songStickers:    make(map[string]stickers, 100),
 		playlists:       make(map[string]*playlist),
+		partitions:      map[string]bool{"default": true},
+		mounts:          map[string]string{"": "/var/lib/mpd/music"},
+		outputs:         newOutputs(),
 		currentPlaylist: newPlaylist(),
 		pos:             0,
 		artwork:         []byte{0x01, 0x02, 0x03, 0x04, 0x05},
-		idleEventc:      make(chan string),
-		idleStartc:      make(chan *idleRequest),
-		idleEndc:        make(chan uint),
+		idleEventc:      make(chan idleEvent),
+		conns:           make(map[*connState]bool),
+		queues:          make(map[*eventQueue]bool),
 	}
 	for i := 0; i < len(s.database); i++ {
-		s.database[i] = make(attrs, 5)
-		filename := fmt.Sprintf("song%04d.ogg", i)
-		s.database[i]["file"] = filename
+		s.database[i] = newSong(i)
+		filename := s.database[i].file
 		s.index[filename] = i
 		s.songStickers[filename] = newStickers()
 	}
@@ -210,7 +454,7 @@ func writeBinaryChunk(p *textproto.Conn, data []byte, offset, length int) {
 	p.W.WriteByte('\n')
 }
 
-func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string) (cmdOk, closed bool) {
+func (s *server) writeResponse(p *textproto.Conn, cs *connState, args []string, okLine string) (cmdOk, closed bool) {
 	if len(args) < 1 {
 		p.PrintfLine("No command given")
 		return
@@ -221,7 +465,18 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 	ackWithCode := func(code int, format string, a ...interface{}) error {
 		return p.PrintfLine(fmt.Sprintf("ACK [%d@0] {%s} %s", code, args[0], format), a...)
 	}
+	if args[0] != "inject" {
+		for _, line := range cs.inject {
+			p.PrintfLine("%s", line)
+		}
+		cs.inject = nil
+	}
 	switch args[0] {
+	case "inject":
+		// Not an MPD command: it makes the server write malformed
+		// responses, by writing the arguments as lines at the start of
+		// the next response.
+		cs.inject = append(cs.inject, args[1:]...)
 	case "close":
 		closed = true
 		return
@@ -230,24 +485,54 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			ack("too few arguments")
 			return
 		}
-		if args[1] == "file" {
-			for _, a := range s.database {
-				p.PrintfLine("file: %s", a["file"])
+		f := func(*song, bool) bool { return true }
+		rest := args[2:]
+		var err error
+		if len(rest) > 0 && !isFilterOption(rest[0]) {
+			f, rest, err = parseSongFilter(rest)
+		}
+		var opts *findOptions
+		if err == nil {
+			opts, err = parseFindOptions(rest)
+		}
+		if err != nil || opts.position != "" {
+			ack("incorrect arguments")
+			return
+		}
+		var songs []*song
+		for _, sg := range s.database {
+			if !sg.removed && f(sg, false) {
+				songs = append(songs, sg)
 			}
 		}
+		writeList(p, songs, canonicalTagType(args[1]), opts.groups)
 	case "listallinfo":
-		if len(args) < 2 || args[1] == "" {
+		if len(args) > 1 && args[1] == "" {
 			ack("too few arguments")
 			return
 		}
-		p.PrintfLine("OK")
+		p.PrintfLine("directory: music")
+		for _, sg := range s.database {
+			if !sg.removed {
+				writeSong(p, sg)
+			}
+		}
+	case "find", "search":
+		songs, opts, err := s.find(args[1:], args[0] == "search")
+		if err != nil || opts.position != "" {
+			ack("incorrect arguments")
+			return
+		}
+		for _, sg := range songs {
+			writeSong(p, sg)
+		}
 	case "lsinfo":
 		if len(args) < 2 || args[1] == "" {
 			ack("too few arguments")
 			return
 		}
-		for _, a := range s.database {
-			p.PrintfLine("file: %s", a["file"])
+		for _, sg := range s.database {
+			p.PrintfLine("file: %s", sg.file)
 			p.PrintfLine("Last-Modified: 2014-07-02T12:32:26Z")
 			p.PrintfLine("Artist: Newcleus")
 			p.PrintfLine("Title: Jam On It")
@@ -299,7 +584,7 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 				ack("integer or range expected")
 				return
 			}
-			end, err := strconv.Atoi(rng[1])
+			end, err = strconv.Atoi(rng[1])
 			if err != nil {
 				ack("integer or range expected")
 				return
@@ -308,10 +593,83 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 				ack("number is negative")
 				return
 			}
+			if end > s.currentPlaylist.Len() {
+				end = s.currentPlaylist.Len()
+			}
+		}
+		if start < 0 || end > s.currentPlaylist.Len() {
+			ack("Bad song index")
+			return
 		}
 
 		for i := start; i < end; i++ {
-			p.PrintfLine("file: %s", s.database[s.currentPlaylist.At(i)]["file"])
+			s.writeQueueEntry(p, i)
+		}
+	case "playlistid":
+		if len(args) > 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		if len(args) == 1 {
+			for i := range s.currentPlaylist.songs {
+				s.writeQueueEntry(p, i)
+			}
+			break
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			ack("invalid song ID")
+			return
+		}
+		i := s.currentPlaylist.Index(id)
+		if i < 0 {
+			ackWithCode(accErrorNoExist, "No such song")
+			return
+		}
+		s.writeQueueEntry(p, i)
+	case "playlistfind", "playlistsearch":
+		f, rest, err := parseSongFilter(args[1:])
+		if err != nil || len(rest) > 0 {
+			ack("incorrect arguments")
+			return
+		}
+		for i, e := range s.currentPlaylist.songs {
+			if f(s.database[e.song], args[0] == "playlistsearch") {
+				s.writeQueueEntry(p, i)
+			}
+		}
+	case "plchanges", "plchangesposid":
+		if len(args) < 2 || len(args) > 3 {
+			ack("wrong number of arguments")
+			return
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			ack("need a positive integer")
+			return
+		}
+		start, end := 0, s.currentPlaylist.Len()
+		if len(args) == 3 {
+			n, err := fmt.Sscanf(args[2], "%d:%d", &start, &end)
+			if n != 2 || err != nil || start < 0 || end < start {
+				ack("integer or range expected")
+				return
+			}
+			if end > s.currentPlaylist.Len() {
+				end = s.currentPlaylist.Len()
+			}
+		}
+		for i := start; i < end; i++ {
+			e := s.currentPlaylist.songs[i]
+			if e.version <= version {
+				continue
+			}
+			if args[0] == "plchanges" {
+				s.writeQueueEntry(p, i)
+			} else {
+				p.PrintfLine("cpos: %d", i)
+				p.PrintfLine("Id: %d", e.id)
+			}
 		}
 	case "listplaylistinfo":
 		if len(args) < 2 {
@@ -324,7 +682,7 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			return
 		}
 		for i := 0; i < pl.Len(); i++ {
-			p.PrintfLine("file: %s", s.database[pl.At(i)]["file"])
+			p.PrintfLine("file: %s", s.database[pl.At(i)].file)
 		}
 	case "playlistadd":
 		if len(args) != 3 {
@@ -413,8 +771,80 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			return
 		}
 		s.currentPlaylist.Append(pl)
+		s.sendEvent(cs, "playlist")
 	case "clear":
 		s.currentPlaylist.Clear()
+		s.sendEvent(cs, "playlist")
+	case "count", "searchcount":
+		songs, opts, err := s.find(args[1:], args[0] == "searchcount")
+		if err != nil || opts.position != "" {
+			ack("incorrect arguments")
+			return
+		}
+		writeCounts(p, songs, opts.groups)
+	case "findadd", "searchadd", "searchaddpl":
+		pl, fargs := s.currentPlaylist, args[1:]
+		if args[0] == "searchaddpl" {
+			if len(args) < 2 {
+				ack("too few arguments")
+				return
+			}
+			if s.playlists[args[1]] == nil {
+				s.playlists[args[1]] = newPlaylist()
+			}
+			pl, fargs = s.playlists[args[1]], args[2:]
+		}
+		songs, opts, err := s.find(fargs, args[0] != "findadd")
+		if err != nil {
+			ack("incorrect arguments")
+			return
+		}
+		pos := -1
+		if opts.position != "" {
+			if pos, err = strconv.Atoi(opts.position); err != nil || pos < 0 || pos > pl.Len() {
+				ack("Bad song index")
+				return
+			}
+		}
+		for _, sg := range songs {
+			pl.Insert(pos, s.index[sg.file])
+			if pos >= 0 {
+				pos++
+			}
+		}
+		if pl == s.currentPlaylist {
+			s.sendEvent(cs, "playlist")
+		} else {
+			s.sendEvent(cs, "stored_playlist")
+		}
+	case "move", "moveid":
+		if len(args) != 3 {
+			ack("wrong number of arguments")
+			return
+		}
+		var start, end int
+		if args[0] == "moveid" {
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				ack("invalid song ID")
+				return
+			}
+			if start = s.currentPlaylist.Index(id); start < 0 {
+				ackWithCode(accErrorNoExist, "No such song")
+				return
+			}
+			end = start + 1
+		} else if n, _ := fmt.Sscanf(args[1], "%d:%d", &start, &end); n == 1 {
+			end = start + 1
+		}
+		to, err := strconv.Atoi(args[2])
+		if err != nil || start < 0 || end <= start || end > s.currentPlaylist.Len() ||
+			to < 0 || to > s.currentPlaylist.Len()-(end-start) {
+			ack("Bad song index")
+			return
+		}
+		s.currentPlaylist.Move(start, end, to)
+		s.sendEvent(cs, "playlist")
 	case "add":
 		if len(args) != 2 {
 			ack("wrong number of arguments")
@@ -426,6 +856,7 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			return
 		}
 		s.currentPlaylist.Add(i)
+		s.sendEvent(cs, "playlist")
 	case "addid":
 		if len(args) < 2 || len(args) > 3 {
 			ack("wrong number of arguments")
@@ -436,7 +867,16 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			ack("URI not found")
 			return
 		}
-		id := s.currentPlaylist.Add(i)
+		pos := -1
+		if len(args) == 3 {
+			var err error
+			if pos, err = strconv.Atoi(args[2]); err != nil || pos < 0 || pos > s.currentPlaylist.Len() {
+				ack("Bad song index")
+				return
+			}
+		}
+		id := s.currentPlaylist.Insert(pos, i)
+		s.sendEvent(cs, "playlist")
 		p.PrintfLine("Id: %d", id)
 	case "prio":
 		if len(args) != 3 {
@@ -496,6 +936,12 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			ack("invalid song ID")
 			return
 		}
+		// Note: like prio, we don't fail on a missing song, as it does
+		// not matter for our test cases
+		if i := s.currentPlaylist.Index(id); i >= 0 {
+			s.currentPlaylist.SetPrio(i, prio)
+			s.sendEvent(cs, "playlist")
+		}
 	case "delete":
 		if len(args) != 2 {
 			ack("wrong number of arguments")
@@ -506,7 +952,7 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			ack("invalid song position")
 			return
 		}
-		s.idleEventc <- "playlist"
+		s.sendEvent(cs, "playlist")
 		if i < 0 || i >= s.currentPlaylist.Len() {
 			ack("invalid song position")
 			return
@@ -522,7 +968,7 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			ack("invalid song ID")
 			return
 		}
-		s.idleEventc <- "playlist"
+		s.sendEvent(cs, "playlist")
 		deleted := false
 		for i, song := range s.currentPlaylist.songs {
 			if song.id == id {
@@ -549,17 +995,17 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 		s.playlists[name] = newPlaylist()
 		s.playlists[name].Append(s.currentPlaylist)
 	case "play", "stop":
-		s.idleEventc <- "player"
+		s.sendEvent(cs, "player")
 		s.state = args[0]
 	case "next":
-		s.idleEventc <- "player"
+		s.sendEvent(cs, "player")
 		if s.pos < 0 || s.pos >= s.currentPlaylist.Len() {
 			s.pos = 0
 			break
 		}
 		s.pos = (s.pos + 1) % s.currentPlaylist.Len()
 	case "previous":
-		s.idleEventc <- "player"
+		s.sendEvent(cs, "player")
 		if s.pos < 0 || s.pos >= s.currentPlaylist.Len() {
 			s.pos = 0
 			break
@@ -573,15 +1019,249 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 		if s.state != "stop" {
 			s.state = args[0]
 		}
+	case "repeat", "random", "single", "consume":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		switch args[1] {
+		case "0", "1":
+		case "oneshot":
+			if args[0] == "single" || args[0] == "consume" {
+				break
+			}
+			fallthrough
+		default:
+			ackWithCode(accErrorArg, "Boolean (0/1) expected: %s", args[1])
+			return
+		}
+		s.options[args[0]] = args[1]
+		s.sendEvent(cs, "options")
+	case "crossfade", "mixrampdb", "mixrampdelay":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		name := args[0]
+		if name == "crossfade" {
+			name = "xfade"
+			if n, err := strconv.Atoi(args[1]); err != nil || n < 0 {
+				ackWithCode(accErrorArg, "Integer expected: %s", args[1])
+				return
+			}
+		} else if _, err := strconv.ParseFloat(args[1], 64); err != nil {
+			ackWithCode(accErrorArg, "Float expected: %s", args[1])
+			return
+		}
+		s.options[name] = args[1]
+		s.sendEvent(cs, "options")
+	case "replay_gain_mode":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		switch args[1] {
+		case "off", "track", "album", "auto":
+		default:
+			ackWithCode(accErrorArg, "Unrecognized replay gain mode")
+			return
+		}
+		s.options["replay_gain_mode"] = args[1]
+		s.sendEvent(cs, "options")
+	case "replay_gain_status":
+		p.PrintfLine("replay_gain_mode: %s", s.options["replay_gain_mode"])
+	case "setvol", "volume":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			ackWithCode(accErrorArg, "Integer expected: %s", args[1])
+			return
+		}
+		if args[0] == "volume" {
+			n += s.volume
+			if n < 0 {
+				n = 0
+			} else if n > 100 {
+				n = 100
+			}
+		}
+		if n < 0 || n > 100 {
+			ackWithCode(accErrorArg, "Invalid volume value")
+			return
+		}
+		s.volume = n
+		s.sendEvent(cs, "mixer")
+	case "getvol":
+		p.PrintfLine("volume: %d", s.volume)
 	case "status":
 		state := s.state
+		p.PrintfLine("partition: %s", cs.partition)
+		p.PrintfLine("volume: %d", s.volume)
+		for _, name := range []string{"repeat", "random", "single", "consume"} {
+			p.PrintfLine("%s: %s", name, s.options[name])
+		}
+		if s.options["xfade"] != "0" {
+			p.PrintfLine("xfade: %s", s.options["xfade"])
+		}
+		p.PrintfLine("mixrampdb: %s", s.options["mixrampdb"])
+		if s.options["mixrampdelay"] != "nan" {
+			p.PrintfLine("mixrampdelay: %s", s.options["mixrampdelay"])
+		}
+		p.PrintfLine("playlist: %d", s.currentPlaylist.version)
+		p.PrintfLine("playlistlength: %d", s.currentPlaylist.Len())
 		p.PrintfLine("state: %s", state)
+		if state != "stop" && s.pos < s.currentPlaylist.Len() {
+			p.PrintfLine("song: %d", s.pos)
+			p.PrintfLine("songid: %d", s.currentPlaylist.songs[s.pos].id)
+		}
 	case "update", "rescan":
 		if len(args) < 2 || args[1] == "" {
 			ack("too few arguments")
 			return
 		}
 		p.PrintfLine("updating_db: 1")
+	case "dbtest":
+		// Not an MPD command: it lets tests change the database, as
+		// an update would.
+		if len(args) < 3 {
+			ack("too few arguments")
+			return
+		}
+		i := -1
+		for j, sg := range s.database {
+			if sg.file == args[2] {
+				i = j
+			}
+		}
+		if i < 0 {
+			ackWithCode(accErrorNoExist, "No such song")
+			return
+		}
+		sg := s.database[i]
+		switch {
+		case args[1] == "remove":
+			sg.removed = true
+			delete(s.index, sg.file)
+		case args[1] == "touch" && len(args) == 4:
+			// Sets the modification time, and adds the song back if
+			// it was removed.
+			t, err := time.Parse(time.RFC3339, args[3])
+			if err != nil {
+				ack("invalid time")
+				return
+			}
+			sg.lastModified = t
+			sg.removed = false
+			s.index[sg.file] = i
+		default:
+			ack("incorrect arguments")
+			return
+		}
+		s.sendEvent(cs, "database")
+	case "subscribe", "unsubscribe":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		switch {
+		case args[0] == "subscribe" && cs.channels[args[1]]:
+			ackWithCode(accErrorExist, "already subscribed to this channel")
+			return
+		case args[0] == "unsubscribe" && !cs.channels[args[1]]:
+			ackWithCode(accErrorNoExist, "not subscribed to this channel")
+			return
+		}
+		if args[0] == "subscribe" {
+			cs.channels[args[1]] = true
+		} else {
+			delete(cs.channels, args[1])
+		}
+	case "channels":
+		channels := make(map[string]bool)
+		for c := range s.conns {
+			for name := range c.channels {
+				channels[name] = true
+			}
+		}
+		var names []string
+		for name := range channels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p.PrintfLine("channel: %s", name)
+		}
+	case "readmessages":
+		for _, m := range cs.messages {
+			p.PrintfLine("channel: %s", m.channel)
+			p.PrintfLine("message: %s", m.text)
+		}
+		cs.messages = nil
+	case "sendmessage":
+		if len(args) != 3 {
+			ack("wrong number of arguments")
+			return
+		}
+		sent := false
+		for c := range s.conns {
+			if c.channels[args[1]] {
+				c.messages = append(c.messages, message{args[1], args[2]})
+				c.events.add(idleEvent{name: "message"})
+				sent = true
+			}
+		}
+		if !sent {
+			ackWithCode(accErrorNoExist, "nobody is subscribed to this channel")
+			return
+		}
+	case "mount":
+		if len(args) != 3 {
+			ack("wrong number of arguments")
+			return
+		}
+		if args[1] == "" || !strings.Contains(args[2], "://") {
+			ack("bad mount point or storage URI")
+			return
+		}
+		if _, ok := s.mounts[args[1]]; ok {
+			ack("mount point busy")
+			return
+		}
+		s.mounts[args[1]] = args[2]
+		s.sendEvent(cs, "mount")
+	case "unmount":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		if args[1] == "" {
+			ack("cannot unmount music directory")
+			return
+		}
+		if _, ok := s.mounts[args[1]]; !ok {
+			ackWithCode(accErrorNoExist, "no such mount")
+			return
+		}
+		delete(s.mounts, args[1])
+		s.sendEvent(cs, "mount")
+	case "listmounts":
+		var paths []string
+		for path := range s.mounts {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			p.PrintfLine("mount: %s", path)
+			p.PrintfLine("storage: %s", s.mounts[path])
+		}
+	case "listneighbors":
+		p.PrintfLine("neighbor: smb://nas")
+		p.PrintfLine("name: NAS (Samba 4.15.13)")
+		p.PrintfLine("neighbor: upnp://uuid:4d696e69-444c-164e-9d41-b827eb54e3ea/0")
+		p.PrintfLine("name: MiniDLNA")
 	case "ping":
 	case "currentsong":
 		if s.currentPlaylist.Len() == 0 {
@@ -590,7 +1270,7 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 		if s.pos >= s.currentPlaylist.Len() {
 			s.pos = 0
 		}
-		p.PrintfLine("file: %s", s.database[s.currentPlaylist.At(s.pos)]["file"])
+		s.writeQueueEntry(p, s.pos)
 	case "albumart":
 		if len(args) < 2 || len(args) > 3 {
 			ack("wrong number of arguments")
@@ -647,14 +1327,121 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 			ack("no artwork found")
 		}
 
+	case "partition":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		if !s.partitions[args[1]] {
+			ackWithCode(accErrorNoExist, "partition does not exist")
+			return
+		}
+		cs.partition = args[1]
+		cs.events.setPartition(args[1])
+	case "newpartition":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		if s.partitions[args[1]] {
+			ackWithCode(accErrorExist, "name already exists")
+			return
+		}
+		s.partitions[args[1]] = true
+	case "delpartition":
+		if len(args) != 2 {
+			ack("wrong number of arguments")
+			return
+		}
+		if !s.partitions[args[1]] || args[1] == "default" {
+			ackWithCode(accErrorNoExist, "no such partition")
+			return
+		}
+		delete(s.partitions, args[1])
+	case "listpartitions":
+		var names []string
+		for name := range s.partitions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p.PrintfLine("partition: %s", name)
+		}
+	case "tagtypes":
+		if len(args) == 1 {
+			for _, name := range cs.tagTypes {
+				p.PrintfLine("tagtype: %s", name)
+			}
+			break
+		}
+		switch args[1] {
+		case "clear":
+			cs.tagTypes = nil
+		case "all":
+			cs.tagTypes = append([]string(nil), knownTagTypes...)
+		case "enable", "disable":
+			for _, name := range args[2:] {
+				i := indexTagType(knownTagTypes, name)
+				if i < 0 {
+					ack("unknown tag type %q", name)
+					return
+				}
+				if j := indexTagType(cs.tagTypes, name); j >= 0 {
+					cs.tagTypes = append(cs.tagTypes[:j], cs.tagTypes[j+1:]...)
+				}
+				if args[1] == "enable" {
+					cs.tagTypes = append(cs.tagTypes, knownTagTypes[i])
+				}
+			}
+		default:
+			ack("unknown sub command %q", args[1])
+			return
+		}
 	case "outputs":
-		p.PrintfLine("outputid: 0")
-		p.PrintfLine("outputenabled: 1")
-		p.PrintfLine("outputname: downstairs")
-		p.PrintfLine("outputid: 1")
-		p.PrintfLine("outputenabled: 0")
-		p.PrintfLine("outputname: upstairs")
-	case "disableoutput", "enableoutput":
+		for i, o := range s.outputs {
+			p.PrintfLine("outputid: %d", i)
+			p.PrintfLine("outputname: %s", o.name)
+			p.PrintfLine("plugin: %s", o.plugin)
+			p.PrintfLine("outputenabled: %s", o.enabled)
+			for _, a := range o.attributes {
+				p.PrintfLine("attribute: %s=%s", a.name, a.value)
+			}
+		}
+	case "disableoutput", "enableoutput", "toggleoutput", "outputset":
+		if len(args) < 2 {
+			ack("too few arguments")
+			return
+		}
+		i, err := strconv.Atoi(args[1])
+		if err != nil || i < 0 || i >= len(s.outputs) {
+			ackWithCode(accErrorNoExist, "No such audio output")
+			return
+		}
+		o := s.outputs[i]
+		switch args[0] {
+		case "disableoutput":
+			o.enabled = "0"
+		case "enableoutput":
+			o.enabled = "1"
+		case "toggleoutput":
+			if o.enabled == "1" {
+				o.enabled = "0"
+			} else {
+				o.enabled = "1"
+			}
+		case "outputset":
+			if len(args) != 4 {
+				ack("wrong number of arguments")
+				return
+			}
+			a := o.attribute(args[2])
+			if a == nil {
+				ack("Unsupported attribute %q", args[2])
+				return
+			}
+			a.value = args[3]
+		}
+		s.sendEvent(cs, "output")
 	case "sticker":
 		if len(args) < 4 {
 			ack("too few arguments")
@@ -748,6 +1535,247 @@ func (s *server) writeResponse(p *textproto.Conn, args []string, okLine string)
 	return
 }
 
+// parseSongFilter parses the filter at the beginning of args, which is
+// either a filter expression or tag/value pairs, and returns the remaining
+// arguments.
+func parseSongFilter(args []string) (f filter, rest []string, err error) {
+	if len(args) > 0 && strings.HasPrefix(args[0], "(") {
+		f, err = parseFilter(args[0])
+		return f, args[1:], err
+	}
+	i := 0
+	for i+1 < len(args) && !isFilterOption(args[i]) {
+		i += 2
+	}
+	if i == 0 {
+		return nil, nil, errBadFilter
+	}
+	pairs := args[:i]
+	return func(sg *song, fold bool) bool {
+		return matchPairs(sg, pairs, fold)
+	}, args[i:], nil
+}
+
+// tagCombinations returns all the combinations of the values of the tags
+// names in the song sg. A missing tag has the empty value.
+func tagCombinations(sg *song, names []string) [][]string {
+	combos := [][]string{nil}
+	for _, n := range names {
+		values := sg.values(n)
+		if len(values) == 0 {
+			values = []string{""}
+		}
+		var next [][]string
+		for _, c := range combos {
+			for _, v := range values {
+				next = append(next, append(append([]string(nil), c...), v))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// canonicalTagType returns the tag name as printed by MPD.
+func canonicalTagType(name string) string {
+	if i := indexTagType(knownTagTypes, name); i >= 0 {
+		return knownTagTypes[i]
+	}
+	return name
+}
+
+// writeList writes the values of tag name in songs, grouped by the values
+// of the tags in groups. Group values are written when they change.
+func writeList(p *textproto.Conn, songs []*song, name string, groups []string) {
+	names := make([]string, 0, len(groups)+1)
+	for _, g := range groups {
+		names = append(names, canonicalTagType(g))
+	}
+	names = append(names, name)
+
+	seen := make(map[string]bool)
+	var rows [][]string
+	for _, sg := range songs {
+		if len(sg.values(name)) == 0 {
+			continue
+		}
+		for _, c := range tagCombinations(sg, names) {
+			key := strings.Join(c, "\x00")
+			if !seen[key] {
+				seen[key] = true
+				rows = append(rows, c)
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		for k := range rows[i] {
+			if rows[i][k] != rows[j][k] {
+				return rows[i][k] < rows[j][k]
+			}
+		}
+		return false
+	})
+	var prev []string
+	for _, row := range rows {
+		changed := prev == nil
+		for k, v := range row {
+			if changed = changed || k == len(row)-1 || v != prev[k]; changed {
+				p.PrintfLine("%s: %s", names[k], v)
+			}
+		}
+		prev = row
+	}
+}
+
+// writeCounts writes the number of songs and their total playtime, for
+// each combination of values of the tags in groups.
+func writeCounts(p *textproto.Conn, songs []*song, groups []string) {
+	type count struct {
+		values   []string
+		songs    int
+		playtime float64
+	}
+	counts := make(map[string]*count)
+	for _, sg := range songs {
+		// A song is counted once for each combination of values.
+		for _, c := range tagCombinations(sg, groups) {
+			key := strings.Join(c, "\x00")
+			if counts[key] == nil {
+				counts[key] = &count{values: c}
+			}
+			counts[key].songs++
+			counts[key].playtime += sg.duration
+		}
+	}
+	if len(groups) == 0 && len(counts) == 0 {
+		counts[""] = &count{}
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		c := counts[k]
+		for i, g := range groups {
+			p.PrintfLine("%s: %s", g, c.values[i])
+		}
+		p.PrintfLine("songs: %d", c.songs)
+		p.PrintfLine("playtime: %d", int(c.playtime))
+	}
+}
+
+// findOptions are the options that may follow the filter of find-like
+// commands.
+type findOptions struct {
+	sort       string // tag to sort by
+	desc       bool   // sort in descending order
+	start, end int    // window; end < 0 means no window
+	position   string // where to add songs
+	groups     []string
+}
+
+func parseFindOptions(args []string) (*findOptions, error) {
+	opts := &findOptions{end: -1}
+	for i := 0; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return nil, errBadFilter
+		}
+		v := args[i+1]
+		switch args[i] {
+		case "sort":
+			opts.sort = strings.TrimPrefix(v, "-")
+			opts.desc = strings.HasPrefix(v, "-")
+		case "window":
+			n, err := fmt.Sscanf(v, "%d:%d", &opts.start, &opts.end)
+			if n != 2 || err != nil || opts.start < 0 || opts.end < opts.start {
+				return nil, errBadFilter
+			}
+		case "position":
+			opts.position = v
+		case "group":
+			opts.groups = append(opts.groups, v)
+		default:
+			return nil, errBadFilter
+		}
+	}
+	return opts, nil
+}
+
+// find returns the songs selected by the filter and options in args.
+func (s *server) find(args []string, fold bool) ([]*song, *findOptions, error) {
+	f, rest, err := parseSongFilter(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts, err := parseFindOptions(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+	var songs []*song
+	for _, sg := range s.database {
+		if !sg.removed && f(sg, fold) {
+			songs = append(songs, sg)
+		}
+	}
+	if opts.sort != "" {
+		key := func(sg *song) string {
+			if opts.sort == "Last-Modified" {
+				return sg.lastModified.Format(time.RFC3339)
+			}
+			if v := sg.values(opts.sort); len(v) > 0 {
+				return v[0]
+			}
+			return ""
+		}
+		sort.SliceStable(songs, func(i, j int) bool {
+			if opts.desc {
+				return key(songs[i]) > key(songs[j])
+			}
+			return key(songs[i]) < key(songs[j])
+		})
+	}
+	if opts.end >= 0 {
+		if opts.end > len(songs) {
+			opts.end = len(songs)
+		}
+		if opts.start > opts.end {
+			opts.start = opts.end
+		}
+		songs = songs[opts.start:opts.end]
+	}
+	return songs, opts, nil
+}
+
+// isFilterOption reports whether arg is a keyword that may follow a filter.
+func isFilterOption(arg string) bool {
+	switch arg {
+	case "sort", "window", "group", "position":
+		return true
+	}
+	return false
+}
+
+// matchPairs reports whether the song sg matches the tag/value pairs in
+// args. If fold is true, values are compared case insensitively and match
+// if they contain the wanted value.
+func matchPairs(sg *song, args []string, fold bool) bool {
+	for i := 0; i+1 < len(args); i += 2 {
+		found := false
+		for _, v := range sg.values(args[i]) {
+			if fold && strings.Contains(strings.ToLower(v), strings.ToLower(args[i+1])) ||
+				!fold && v == args[i+1] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 type requestType int
 
 const (
@@ -805,32 +1833,104 @@ func (s *server) readRequest(p *textproto.Conn) (*request, error) {
 	return &request{typ: simple, args: args}, nil
 }
 
-type idleRequest struct {
-	endTokenc  chan uint   // for token used to end event broadcast
-	eventc     chan string // for subsystem name
-	subsystems []string    // subsystems to listen for changes
+// idleEvent is an idle event caused by a connection in partition.
+type idleEvent struct {
+	name      string
+	partition string
 }
 
-func (s *server) writeIdleResponse(p *textproto.Conn, id uint, quit chan bool, subsystems []string) {
-	p.StartResponse(id)
-	defer p.EndResponse(id)
+// partitionEvents are the subsystems whose events are only reported to
+// the connections in the partition where they occurred.
+var partitionEvents = map[string]bool{
+	"player":   true,
+	"mixer":    true,
+	"playlist": true,
+	"options":  true,
+}
+
+// sendEvent sends the event name caused by the connection cs.
+func (s *server) sendEvent(cs *connState, name string) {
+	s.idleEventc <- idleEvent{name: name, partition: cs.partition}
+}
 
-	req := &idleRequest{
-		endTokenc:  make(chan uint),
-		eventc:     make(chan string, 1),
-		subsystems: subsystems,
+// eventQueue holds the idle events of a connection that have not been
+// reported yet.
+type eventQueue struct {
+	mu        sync.Mutex
+	pending   map[string]bool
+	partition string        // partition of the connection
+	notify    chan struct{} // signaled when an event is added
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{
+		pending:   make(map[string]bool),
+		partition: "default",
+		notify:    make(chan struct{}, 1),
 	}
-	s.idleStartc <- req
-	token := <-req.endTokenc
+}
+
+func (q *eventQueue) setPartition(name string) {
+	q.mu.Lock()
+	q.partition = name
+	q.mu.Unlock()
+}
+
+// add adds the event e, unless it occurred in another partition.
+func (q *eventQueue) add(e idleEvent) {
+	q.mu.Lock()
+	if partitionEvents[e.name] && e.partition != q.partition {
+		q.mu.Unlock()
+		return
+	}
+	q.pending[e.name] = true
+	q.mu.Unlock()
 	select {
-	case name := <-req.eventc:
-		p.PrintfLine("changed: %s", name)
-		p.PrintfLine("OK")
-		<-quit
-	case <-quit:
-		p.PrintfLine("OK")
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take removes and returns the pending events of subsystems, or of all
+// subsystems if none is given.
+func (q *eventQueue) take(subsystems []string) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var names []string
+	for name := range q.pending {
+		want := len(subsystems) == 0
+		for _, sub := range subsystems {
+			want = want || sub == name
+		}
+		if want {
+			names = append(names, name)
+			delete(q.pending, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *server) writeIdleResponse(p *textproto.Conn, id uint, quit chan bool, q *eventQueue, subsystems []string) {
+	p.StartResponse(id)
+	defer p.EndResponse(id)
+
+	for {
+		if changed := q.take(subsystems); len(changed) > 0 {
+			for _, name := range changed {
+				p.PrintfLine("changed: %s", name)
+			}
+			p.PrintfLine("OK")
+			<-quit
+			return
+		}
+		select {
+		case <-q.notify:
+		case <-quit:
+			p.PrintfLine("OK")
+			return
+		}
 	}
-	s.idleEndc <- token
 }
 
 func (s *server) handleConnection(p *textproto.Conn) {
@@ -841,14 +1941,34 @@ func (s *server) handleConnection(p *textproto.Conn) {
 	p.PrintfLine("OK MPD gompd0.1")
 	p.EndResponse(id)
 
+	cs := newConnState()
 	endIdle := make(chan bool)
 	inIdle := false
+	events := cs.events
+	s.mu.Lock()
+	s.conns[cs] = true
+	s.mu.Unlock()
+	s.queuesMu.Lock()
+	s.queues[events] = true
+	s.queuesMu.Unlock()
+	defer func() {
+		s.queuesMu.Lock()
+		delete(s.queues, events)
+		s.queuesMu.Unlock()
+		s.mu.Lock()
+		delete(s.conns, cs)
+		s.mu.Unlock()
+	}()
 	defer p.Close()
 	for {
 		id := p.Next()
 		p.StartRequest(id)
 		req, err := s.readRequest(p)
 		if err != nil {
+			if inIdle {
+				// Let the idle response finish.
+				endIdle <- true
+			}
 			return
 		}
 		// We need to do this inside request because idle response
@@ -860,7 +1980,7 @@ func (s *server) handleConnection(p *textproto.Conn) {
 
 		if req.typ == idle {
 			inIdle = true
-			go s.writeIdleResponse(p, id, endIdle, req.args[1:])
+			go s.writeIdleResponse(p, id, endIdle, events, req.args[1:])
 			// writeIdleResponse does it's own StartResponse/EndResponse
 			continue
 		}
@@ -869,14 +1989,16 @@ func (s *server) handleConnection(p *textproto.Conn) {
 		if inIdle {
 			inIdle = false
 		}
+		s.mu.Lock()
 		switch req.typ {
 		case noIdle:
 		case commandListOk:
 			var ok, closed bool
 			ok = true
 			for _, args := range req.cmdList {
-				ok, closed = s.writeResponse(p, args, "list_OK")
+				ok, closed = s.writeResponse(p, cs, args, "list_OK")
 				if closed {
+					s.mu.Unlock()
 					return
 				}
 				if !ok {
@@ -887,83 +2009,25 @@ func (s *server) handleConnection(p *textproto.Conn) {
 				p.PrintfLine("OK")
 			}
 		case simple:
-			if _, closed := s.writeResponse(p, req.args, "OK"); closed {
+			if _, closed := s.writeResponse(p, cs, req.args, "OK"); closed {
+				s.mu.Unlock()
 				return
 			}
 		}
+		s.mu.Unlock()
 		p.EndResponse(id)
 	}
 }
 
-var knownSubsystems = []string{
-	"database",
-	"update",
-	"stored_playlist",
-	"playlist",
-	"player",
-	"mixer",
-	"output",
-	"options",
-}
-
-func indexID(v []uint, id uint) int {
-	for i, n := range v {
-		if id == n {
-			return i
-		}
-	}
-	return -1
-}
-
-func deleteID(v []uint, id uint) []uint {
-	i := indexID(v, id)
-	if i < 0 {
-		return v
-	}
-	copy(v[i:], v[i+1:])
-	return v[:len(v)-1]
-}
-
+// broadcastIdleEvents adds the events sent on s.idleEventc to the event
+// queues of the connections.
 func (s *server) broadcastIdleEvents() {
-	clientChans := make(map[uint]chan string)
-	subsys := make(map[string][]uint)
-	for _, name := range knownSubsystems {
-		subsys[name] = make([]uint, 0)
-	}
-	token := uint(0)
-	for {
-		select {
-		case req := <-s.idleStartc:
-			clientChans[token] = req.eventc
-			names := req.subsystems
-			if len(req.subsystems) == 0 {
-				names = knownSubsystems
-			}
-			for _, name := range names {
-				if _, ok := subsys[name]; !ok {
-					subsys[name] = make([]uint, 0)
-				}
-				subsys[name] = append(subsys[name], token)
-			}
-			req.endTokenc <- token
-			token++
-
-		case client := <-s.idleEndc:
-			delete(clientChans, client)
-			for name := range subsys {
-				subsys[name] = deleteID(subsys[name], client)
-			}
-
-		case name := <-s.idleEventc:
-			if clients, ok := subsys[name]; ok {
-				for _, c := range clients {
-					select {
-					case clientChans[c] <- name:
-					default:
-					}
-				}
-			}
+	for e := range s.idleEventc {
+		s.queuesMu.Lock()
+		for q := range s.queues {
+			q.add(e)
 		}
+		s.queuesMu.Unlock()
 	}
 }
 
@@ -0,0 +1,165 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+// SongIterator iterates over the songs returned by MPD as they are read
+// from the connection, without holding the whole response in memory. It
+// is returned by methods such as Client.ListAllInfoIter.
+//
+// The connection is locked until the iterator has been drained or closed:
+// other requests on the same Client wait until then. Always call Close
+// once done with the iterator, typically with defer. A SongIterator must
+// not be used by several goroutines at once.
+//
+//	it, err := c.ListAllInfoIter("/")
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Song().URI)
+//	}
+//	return it.Err()
+type SongIterator struct {
+	c    *Client
+	cmd  string            // command sent
+	end  func(error) error // releases the connection, nil once done
+	id   uint
+	r    songReader
+	next *Song // first song, read in advance
+	song *Song
+	err  error
+}
+
+// SongIter sends command to server and returns an iterator over the list of
+// songs returned in response. If MPD fails the command, the error is
+// returned and no iterator is created.
+//
+// Unlike other Command methods, the command is not sent again if the
+// connection is lost and the client reconnects automatically.
+func (cmd *Command) SongIter() (*SongIterator, error) {
+	c := cmd.client
+	end, err := c.begin()
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.cmd("%v", cmd.cmd)
+	if err != nil {
+		return nil, end(err)
+	}
+	c.text.StartResponse(id)
+	it := &SongIterator{
+		c:   c,
+		cmd: cmd.cmd,
+		end: end,
+		id:  id,
+		r:   songReader{c: c, terminator: "OK"},
+	}
+	it.next, err = it.r.read()
+	if err != nil {
+		it.finish(err)
+		return nil, it.err
+	}
+	if it.next == nil {
+		it.finish(nil)
+	}
+	return it, nil
+}
+
+// Next advances the iterator to the next song, which is then available
+// through Song. It returns false when there are no more songs or an error
+// occurred, in which case the connection has been released.
+func (it *SongIterator) Next() bool {
+	it.song = nil
+	if it.next != nil {
+		it.song, it.next = it.next, nil
+		return true
+	}
+	if it.end == nil {
+		return false
+	}
+	if it.c.isClosed() {
+		it.finish(ErrClosed)
+		return false
+	}
+	song, err := it.r.read()
+	if err != nil || song == nil {
+		it.finish(err)
+		return false
+	}
+	it.song = song
+	return true
+}
+
+// Song returns the current song, or nil if Next hasn't been called or
+// returned false.
+func (it *SongIterator) Song() *Song {
+	return it.song
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *SongIterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration and releases the connection. The rest of the
+// response is read and discarded, so that the connection can be used for
+// further requests. Close returns the same error as Err.
+func (it *SongIterator) Close() error {
+	it.song, it.next = nil, nil
+	if it.end == nil {
+		return it.err
+	}
+	if it.c.isClosed() {
+		// The connection was closed by Client.Close.
+		it.finish(ErrClosed)
+		return it.err
+	}
+	var err error
+	for !it.r.done {
+		var line string
+		if line, err = it.c.readLine(); err != nil {
+			break
+		}
+		it.r.done = line == "OK"
+	}
+	it.finish(err)
+	return it.err
+}
+
+// finish ends the exchange with the result err of the iteration.
+func (it *SongIterator) finish(err error) {
+	if it.c.text != nil {
+		it.c.text.EndResponse(it.id)
+	}
+	it.err = withCommand(it.end(err), it.cmd)
+	it.end = nil
+}
+
+// ListAllInfoIter is like ListAllInfo, but returns an iterator over the
+// songs. Directories are skipped.
+func (c *Client) ListAllInfoIter(uri string) (*SongIterator, error) {
+	return c.Command("listallinfo %s", uri).SongIter()
+}
+
+// FindIter is like Find, but returns an iterator over the songs found.
+func (c *Client) FindIter(args ...string) (*SongIterator, error) {
+	return c.Command("find %s", Quoted(quoteArgs(args))).SongIter()
+}
+
+// SearchIter is like Search, but returns an iterator over the songs found.
+func (c *Client) SearchIter(args ...string) (*SongIterator, error) {
+	return c.Command("search %s", Quoted(quoteArgs(args))).SongIter()
+}
+
+// PlaylistInfoIter is like PlaylistInfo, but returns an iterator over the
+// songs.
+func (c *Client) PlaylistInfoIter(start, end int) (*SongIterator, error) {
+	cmd, err := c.playlistInfoCommand(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.SongIter()
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListAllInfoIter(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	want, err := cli.ListAllSongs("/")
+	if err != nil {
+		t.Fatalf("Client.ListAllSongs failed: %s", err)
+	}
+	it, err := cli.ListAllInfoIter("/")
+	if err != nil {
+		t.Fatalf("Client.ListAllInfoIter failed: %s", err)
+	}
+	defer it.Close()
+	var songs []Song
+	for it.Next() {
+		songs = append(songs, *it.Song())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("SongIterator.Err = %s", err)
+	}
+	if !reflect.DeepEqual(songs, want) {
+		t.Errorf("SongIterator returned %d songs, different from Client.ListAllSongs", len(songs))
+	}
+	if it.Next() || it.Song() != nil {
+		t.Errorf("SongIterator.Next succeeded after the end of the list")
+	}
+	if err := cli.Ping(); err != nil {
+		t.Errorf("Client.Ping failed: %s", err)
+	}
+}
+
+func TestSongIterClose(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	it, err := cli.FindIter("artist", "Guest")
+	if err != nil {
+		t.Fatalf("Client.FindIter failed: %s", err)
+	}
+	if !it.Next() {
+		t.Fatalf("SongIterator.Next failed: %v", it.Err())
+	}
+
+	// The connection is locked until the iterator is closed.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := cli.WithContext(ctx).Ping(); err != context.DeadlineExceeded {
+		t.Fatalf("Client.Ping during iteration = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := it.Close(); err != nil {
+		t.Fatalf("SongIterator.Close failed: %s", err)
+	}
+	if it.Next() {
+		t.Errorf("SongIterator.Next succeeded after Close")
+	}
+	songs, err := cli.PlaylistInfoIter(-1, -1)
+	if err != nil {
+		t.Fatalf("Client.PlaylistInfoIter failed: %s", err)
+	}
+	songs.Close()
+	if err := cli.Ping(); err != nil {
+		t.Errorf("Client.Ping failed: %s", err)
+	}
+}
+
+func TestCloseDuringSongIter(t *testing.T) {
+	cli := localDial(t)
+
+	it, err := cli.ListAllInfoIter("/")
+	if err != nil {
+		t.Fatalf("Client.ListAllInfoIter failed: %s", err)
+	}
+	if !it.Next() {
+		t.Fatalf("SongIterator.Next failed: %v", it.Err())
+	}
+
+	// The iterator holds the connection but is not advanced.
+	closed := make(chan struct{})
+	go func() {
+		cli.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatalf("Client.Close hangs while an iterator is open")
+	}
+	if it.Next() {
+		t.Errorf("SongIterator.Next succeeded after Client.Close")
+	}
+	if err := it.Close(); err != ErrClosed {
+		t.Errorf("SongIterator.Close = %v; want %v", err, ErrClosed)
+	}
+	if err := cli.Ping(); err != ErrClosed {
+		t.Errorf("Client.Ping after Close = %v; want %v", err, ErrClosed)
+	}
+}
+
+func TestSongIterError(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	if _, err := cli.SearchIter("artist"); err == nil {
+		t.Fatalf("Client.SearchIter with missing value succeeded")
+	}
+	if err := cli.Ping(); err != nil {
+		t.Errorf("Client.Ping failed: %s", err)
+	}
+}
@@ -0,0 +1,412 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// libraryTags are the tags indexed by Library.
+var libraryTags = []Tag{TagArtist, TagAlbum, TagGenre}
+
+// fetchBatch is the number of songs fetched by each command list when the
+// library is refreshed.
+const fetchBatch = 64
+
+// LibraryChange describes how the database changed, as reported by
+// Library. Songs are identified by their URI.
+type LibraryChange struct {
+	Added   []string // songs added to the database
+	Updated []string // songs modified since they were loaded
+	Removed []string // songs removed from the database
+}
+
+func (lc *LibraryChange) empty() bool {
+	return len(lc.Added) == 0 && len(lc.Updated) == 0 && len(lc.Removed) == 0
+}
+
+// Library keeps a local copy of the database of MPD, so that it can be
+// browsed and searched without sending requests to MPD. Songs are indexed
+// by URI, artist, album and genre.
+//
+// The database is loaded once, and refreshed when MPD reports a change in
+// the database subsystem: songs modified since the last refresh are loaded
+// again, and the list of files is checked for songs that were removed or
+// whose modification time changed.
+//
+// Each refresh is reported on the Change channel, and errors on the Error
+// channel. Both channels must be received from, otherwise refreshes stall.
+type Library struct {
+	conn    *Client  // client connection to MPD
+	watcher *Watcher // watches for changes of the database
+	exit    chan bool
+	done    chan bool
+
+	tagTypes []string // tag types included in the responses to conn
+
+	mu     sync.RWMutex // guards the fields below
+	songs  map[string]*Song
+	index  map[Tag]map[string]map[string]*Song // tag, value and URI to song
+	newest time.Time                           // latest modification time
+
+	Change chan LibraryChange // change channel
+	Error  chan error         // error channel
+}
+
+// NewLibrary connects to MPD server, loads the database, and starts keeping
+// it up to date.
+func NewLibrary(net, addr, passwd string) (*Library, error) {
+	conn, err := DialWithOptions(net, addr, WithPassword(passwd))
+	if err != nil {
+		return nil, err
+	}
+	// Start watching before loading, so that no change is missed.
+	w, err := NewWatcher(net, addr, passwd, "database")
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	l := &Library{
+		conn:    conn,
+		watcher: w,
+		exit:    make(chan bool),
+		done:    make(chan bool),
+		songs:   make(map[string]*Song),
+		index:   make(map[Tag]map[string]map[string]*Song),
+		Change:  make(chan LibraryChange),
+		Error:   make(chan error),
+	}
+	for _, tag := range libraryTags {
+		l.index[tag] = make(map[string]map[string]*Song)
+	}
+	if l.tagTypes, err = conn.TagTypes(); err != nil {
+		w.Close()
+		conn.Close()
+		return nil, err
+	}
+	if err := l.load(); err != nil {
+		w.Close()
+		conn.Close()
+		return nil, err
+	}
+	go l.loop()
+	return l, nil
+}
+
+// Close stops updating the database, closes the Change and Error channels,
+// and the connections to MPD server.
+func (l *Library) Close() error {
+	close(l.exit)
+	err := l.watcher.Close()
+	<-l.done
+	if cerr := l.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Len returns the number of songs in the database.
+func (l *Library) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.songs)
+}
+
+// Song returns the song with the given URI. The tags of the song must not
+// be modified.
+func (l *Library) Song(uri string) (Song, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if s, ok := l.songs[uri]; ok {
+		return *s, true
+	}
+	return Song{}, false
+}
+
+// Values returns the sorted values of tag in the database, like List. Tags
+// that are not indexed are looked up in every song.
+func (l *Library) Values(tag Tag) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var values []string
+	if idx, ok := l.index[tag]; ok {
+		for v := range idx {
+			values = append(values, v)
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, s := range l.songs {
+			for _, v := range s.Tags[string(tag)] {
+				if !seen[v] {
+					seen[v] = true
+					values = append(values, v)
+				}
+			}
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// Songs returns the songs whose tag has the given value, sorted by URI.
+// Tags that are not indexed are looked up in every song.
+func (l *Library) Songs(tag Tag, value string) []Song {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var songs []Song
+	if idx, ok := l.index[tag]; ok {
+		for _, s := range idx[value] {
+			songs = append(songs, *s)
+		}
+	} else {
+		for _, s := range l.songs {
+			if hasValue(s, tag, value) {
+				songs = append(songs, *s)
+			}
+		}
+	}
+	sortSongs(songs)
+	return songs
+}
+
+// Search returns the songs whose URI or any tag contains query, ignoring
+// case, sorted by URI.
+func (l *Library) Search(query string) []Song {
+	query = strings.ToLower(query)
+	contains := func(s string) bool {
+		return strings.Contains(strings.ToLower(s), query)
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var songs []Song
+	for _, s := range l.songs {
+		if contains(s.URI) {
+			songs = append(songs, *s)
+			continue
+		}
+	tags:
+		for _, values := range s.Tags {
+			for _, v := range values {
+				if contains(v) {
+					songs = append(songs, *s)
+					break tags
+				}
+			}
+		}
+	}
+	sortSongs(songs)
+	return songs
+}
+
+func hasValue(s *Song, tag Tag, value string) bool {
+	for _, v := range s.Tags[string(tag)] {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortSongs(songs []Song) {
+	sort.Slice(songs, func(i, j int) bool { return songs[i].URI < songs[j].URI })
+}
+
+func (l *Library) loop() {
+	defer func() {
+		close(l.Change)
+		close(l.Error)
+		close(l.done)
+	}()
+	for {
+		select {
+		case _, ok := <-l.watcher.Event:
+			if !ok {
+				return
+			}
+			lc, err := l.refresh()
+			switch {
+			case err != nil:
+				if !l.sendError(err) {
+					return
+				}
+			case !lc.empty():
+				select {
+				case l.Change <- *lc:
+				case <-l.exit:
+					return
+				}
+			}
+		case err, ok := <-l.watcher.Error:
+			if !ok || !l.sendError(err) {
+				return
+			}
+		case <-l.exit:
+			return
+		}
+	}
+}
+
+func (l *Library) sendError(err error) bool {
+	select {
+	case l.Error <- err:
+		return true
+	case <-l.exit:
+		return false
+	}
+}
+
+// load loads the whole database.
+func (l *Library) load() error {
+	it, err := l.conn.Command("listallinfo").SongIter()
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	var songs []*Song
+	for it.Next() {
+		songs = append(songs, it.Song())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range songs {
+		l.add(s)
+	}
+	return nil
+}
+
+// refresh loads the songs modified since the last refresh, and the songs
+// whose modification time changed otherwise, e.g. when a file is replaced by
+// an older one. It removes the songs that are no longer in the database.
+func (l *Library) refresh() (*LibraryChange, error) {
+	l.mu.RLock()
+	since := l.newest
+	l.mu.RUnlock()
+
+	filter := ModifiedSince(since)
+	if since.IsZero() {
+		// The library is empty: all the songs are new.
+		filter = Filter{}
+	}
+	modified, err := l.conn.Command("find %s", filter.arg()).Songs()
+	if err != nil {
+		return nil, err
+	}
+	// List the files with their modification time only.
+	cl := l.conn.BeginCommandList()
+	cl.TagTypesClear()
+	files := cl.songs("listallinfo")
+	if len(l.tagTypes) > 0 {
+		cl.TagTypesEnable(l.tagTypes...)
+	}
+	if err := cl.End(); err != nil {
+		return nil, err
+	}
+	present := make(map[string]time.Time, len(files.songs)) // URI to modification time
+	for _, f := range files.songs {
+		present[f.URI] = f.LastModified
+	}
+	found := make(map[string]bool, len(modified))
+	for i := range modified {
+		found[modified[i].URI] = true
+	}
+	// Songs added or replaced with an older modification time are not
+	// found by modified-since.
+	l.mu.RLock()
+	var missing []string
+	for uri, mtime := range present {
+		if old, ok := l.songs[uri]; !found[uri] && (!ok || !old.LastModified.Equal(mtime)) {
+			missing = append(missing, uri)
+		}
+	}
+	l.mu.RUnlock()
+	for len(missing) > 0 {
+		// Fetch the songs in batches, e.g. when a storage is mounted.
+		n := len(missing)
+		if n > fetchBatch {
+			n = fetchBatch
+		}
+		cl := l.conn.BeginCommandList()
+		found := make([]*promisedSongs, n)
+		for i, uri := range missing[:n] {
+			found[i] = cl.songs("find " + quote(Eq(TagFile, uri).arg()))
+		}
+		if err := cl.End(); err != nil {
+			return nil, err
+		}
+		for _, ps := range found {
+			modified = append(modified, ps.songs...)
+		}
+		missing = missing[n:]
+	}
+
+	lc := new(LibraryChange)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for uri := range l.songs {
+		if _, ok := present[uri]; !ok {
+			l.remove(uri)
+			lc.Removed = append(lc.Removed, uri)
+		}
+	}
+	for i := range modified {
+		s := &modified[i]
+		old, ok := l.songs[s.URI]
+		switch {
+		case !ok:
+			lc.Added = append(lc.Added, s.URI)
+		case !reflect.DeepEqual(old, s):
+			l.remove(s.URI)
+			lc.Updated = append(lc.Updated, s.URI)
+		default:
+			continue
+		}
+		l.add(s)
+	}
+	sort.Strings(lc.Added)
+	sort.Strings(lc.Updated)
+	sort.Strings(lc.Removed)
+	return lc, nil
+}
+
+// add adds s to the database and its indexes. l.mu must be held.
+func (l *Library) add(s *Song) {
+	l.songs[s.URI] = s
+	for tag, idx := range l.index {
+		for _, v := range s.Tags[string(tag)] {
+			if idx[v] == nil {
+				idx[v] = make(map[string]*Song)
+			}
+			idx[v][s.URI] = s
+		}
+	}
+	if s.LastModified.After(l.newest) {
+		l.newest = s.LastModified
+	}
+}
+
+// remove removes the song with the given URI from the database and its
+// indexes. l.mu must be held.
+func (l *Library) remove(uri string) {
+	s, ok := l.songs[uri]
+	if !ok {
+		return
+	}
+	delete(l.songs, uri)
+	for tag, idx := range l.index {
+		for _, v := range s.Tags[string(tag)] {
+			delete(idx[v], uri)
+			if len(idx[v]) == 0 {
+				delete(idx, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func nextLibraryChange(t *testing.T, l *Library) LibraryChange {
+	t.Helper()
+	select {
+	case lc := <-l.Change:
+		return lc
+	case err := <-l.Error:
+		t.Fatalf("Library failed: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no library change received")
+	}
+	panic("unreachable")
+}
+
+// touchSong sets the modification time of a song in the test server,
+// adding it back to the database if it was removed.
+func touchSong(t *testing.T, cli *Client, uri string, modified time.Time) {
+	t.Helper()
+	if err := cli.Command("dbtest touch %s %s", uri, modified.UTC().Format(time.RFC3339)).OK(); err != nil {
+		t.Fatalf("dbtest touch failed: %s", err)
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	network, addr := localAddr()
+	l, err := NewLibrary(network, addr, "")
+	if err != nil {
+		t.Fatalf("NewLibrary failed: %s", err)
+	}
+	// Restore the songs changed by the test once the library is closed.
+	added := time.Date(2014, 7, 2, 12, 32, 26, 0, time.UTC)
+	defer touchSong(t, cli, "song0098.ogg", added.Add(98*time.Minute))
+	defer touchSong(t, cli, "song0099.ogg", added.Add(99*time.Minute))
+	defer l.Close()
+
+	if n := l.Len(); n != 100 {
+		t.Errorf("Library.Len is %d; want 100", n)
+	}
+	songs, err := cli.Command("find %s", Eq(TagFile, "song0042.ogg").arg()).Songs()
+	if err != nil {
+		t.Fatalf("find failed: %s", err)
+	}
+	if s, ok := l.Song("song0042.ogg"); !ok || !reflect.DeepEqual(s, songs[0]) {
+		t.Errorf("Library.Song returned %+v, %v; want %+v", s, ok, songs[0])
+	}
+	if _, ok := l.Song("missing.ogg"); ok {
+		t.Errorf("Library.Song found a missing song")
+	}
+	artists, err := cli.List("Artist")
+	if err != nil {
+		t.Fatalf("Client.List failed: %s", err)
+	}
+	if got := l.Values(TagArtist); !reflect.DeepEqual(got, artists) {
+		t.Errorf("Library.Values returned %v; want %v", got, artists)
+	}
+	if got := l.Values(TagTrack); len(got) != 10 {
+		t.Errorf("Library.Values returned %v; want 10 tracks", got)
+	}
+	if n := len(l.Songs(TagGenre, "Pop")); n != 34 {
+		t.Errorf("Library.Songs returned %d pop songs; want 34", n)
+	}
+	if n := len(l.Songs(TagTrack, "1")); n != 10 {
+		t.Errorf("Library.Songs returned %d songs of track 1; want 10", n)
+	}
+	if got := l.Search("TITLE 47"); len(got) != 1 || got[0].URI != "song0047.ogg" {
+		t.Errorf("Library.Search returned %+v; want song0047.ogg", got)
+	}
+
+	if err := cli.Command("dbtest remove %s", "song0099.ogg").OK(); err != nil {
+		t.Fatalf("dbtest remove failed: %s", err)
+	}
+	lc := nextLibraryChange(t, l)
+	if want := (LibraryChange{Removed: []string{"song0099.ogg"}}); !reflect.DeepEqual(lc, want) {
+		t.Errorf("removing a song changed the library with %+v; want %+v", lc, want)
+	}
+	if n := len(l.Songs(TagAlbum, "Album 9")); n != 9 || l.Len() != 99 {
+		t.Errorf("library has %d songs in album 9 and %d in total; want 9 and 99", n, l.Len())
+	}
+
+	// Adding a song back with an older modification time.
+	touchSong(t, cli, "song0099.ogg", added)
+	lc = nextLibraryChange(t, l)
+	if want := (LibraryChange{Added: []string{"song0099.ogg"}}); !reflect.DeepEqual(lc, want) {
+		t.Errorf("adding a song changed the library with %+v; want %+v", lc, want)
+	}
+
+	modified := time.Now().Add(time.Hour).Truncate(time.Second)
+	touchSong(t, cli, "song0098.ogg", modified)
+	lc = nextLibraryChange(t, l)
+	if want := (LibraryChange{Updated: []string{"song0098.ogg"}}); !reflect.DeepEqual(lc, want) {
+		t.Errorf("modifying a song changed the library with %+v; want %+v", lc, want)
+	}
+	if s, _ := l.Song("song0098.ogg"); !s.LastModified.Equal(modified) {
+		t.Errorf("song modified at %s; want %s", s.LastModified, modified)
+	}
+}
+
+func TestLibraryRefresh(t *testing.T) {
+	network, addr := localServer(t)
+	l, err := NewLibrary(network, addr, "")
+	if err != nil {
+		t.Fatalf("NewLibrary failed: %s", err)
+	}
+	defer l.Close()
+
+	for name, newest := range map[string]time.Time{
+		// All the songs are found by modified-since.
+		"empty": {},
+		// All the songs are older than the newest one, so they are
+		// fetched in batches.
+		"old": time.Now().Add(time.Hour),
+	} {
+		t.Run(name, func(t *testing.T) {
+			l.mu.Lock()
+			l.songs = make(map[string]*Song)
+			for tag := range l.index {
+				l.index[tag] = make(map[string]map[string]*Song)
+			}
+			l.newest = newest
+			l.mu.Unlock()
+
+			lc, err := l.refresh()
+			if err != nil {
+				t.Fatalf("Library.refresh failed: %s", err)
+			}
+			if len(lc.Added) != 100 || len(lc.Updated) != 0 || len(lc.Removed) != 0 {
+				t.Errorf("Library.refresh added %d, updated %d and removed %d songs; want 100 added",
+					len(lc.Added), len(lc.Updated), len(lc.Removed))
+			}
+			if n := l.Len(); n != 100 {
+				t.Errorf("Library.Len is %d; want 100", n)
+			}
+		})
+	}
+}
+
+func TestLibraryRefreshReplaced(t *testing.T) {
+	network, addr := localServer(t)
+	l, err := NewLibrary(network, addr, "")
+	if err != nil {
+		t.Fatalf("NewLibrary failed: %s", err)
+	}
+	defer l.Close()
+
+	// Pretend that the file of a song was replaced by one older than the
+	// song that was loaded, which modified-since doesn't find.
+	const uri = "song0001.ogg"
+	l.mu.Lock()
+	old := *l.songs[uri]
+	old.LastModified = old.LastModified.Add(time.Hour)
+	old.Tags = map[string][]string{"Title": {"replaced"}}
+	l.remove(uri)
+	l.add(&old)
+	l.mu.Unlock()
+
+	lc, err := l.refresh()
+	if err != nil {
+		t.Fatalf("Library.refresh failed: %s", err)
+	}
+	if len(lc.Added) != 0 || !reflect.DeepEqual(lc.Updated, []string{uri}) || len(lc.Removed) != 0 {
+		t.Errorf("Library.refresh returned %+v; want only %q updated", lc, uri)
+	}
+	if s, _ := l.Song(uri); reflect.DeepEqual(s, old) {
+		t.Errorf("Library.refresh did not load %q again", uri)
+	}
+}
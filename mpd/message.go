@@ -0,0 +1,62 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+// Message is a message sent by a client to a channel, received by the
+// clients subscribed to this channel.
+type Message struct {
+	Channel string
+	Text    string
+}
+
+// Subscribe subscribes the client to channel, so that it receives the
+// messages sent to channel. The channel is created if it doesn't exist.
+// Subscriptions are lost if the client reconnects.
+func (c *Client) Subscribe(channel string) error {
+	return c.Command("subscribe %s", channel).OK()
+}
+
+// Unsubscribe unsubscribes the client from channel.
+func (c *Client) Unsubscribe(channel string) error {
+	return c.Command("unsubscribe %s", channel).OK()
+}
+
+// Channels returns the channels with at least one subscribed client.
+func (c *Client) Channels() ([]string, error) {
+	return c.Command("channels").Strings("channel")
+}
+
+// ReadMessages returns the messages received on the channels the client
+// is subscribed to since the last call. MPD reports new messages with an
+// event in the "message" subsystem.
+func (c *Client) ReadMessages() (msgs []Message, err error) {
+	err = c.Command("readmessages").exec(func() error {
+		msgs, err = c.readMessages()
+		return err
+	})
+	return
+}
+
+// readMessages reads a list of channel/message pairs.
+func (c *Client) readMessages() ([]Message, error) {
+	attrs, err := c.readAttrsList("channel", "OK")
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]Message, len(attrs))
+	for i, a := range attrs {
+		text, ok := a["message"]
+		if !ok {
+			return nil, protocolError("missing message on channel "+a["channel"], "")
+		}
+		msgs[i] = Message{Channel: a["channel"], Text: text}
+	}
+	return msgs, nil
+}
+
+// SendMessage sends text to the clients subscribed to channel.
+func (c *Client) SendMessage(channel, text string) error {
+	return c.Command("sendmessage %s %s", channel, text).OK()
+}
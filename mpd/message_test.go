@@ -0,0 +1,93 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMessages(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+	sender := localDial(t)
+	defer teardown(sender, t)
+
+	if err := cli.Subscribe("kiosk"); err != nil {
+		t.Fatalf("Client.Subscribe failed: %s", err)
+	}
+	if err := cli.Subscribe("kiosk"); err == nil {
+		t.Errorf("Client.Subscribe succeeded twice")
+	}
+	channels, err := sender.Channels()
+	if err != nil {
+		t.Fatalf("Client.Channels failed: %s", err)
+	}
+	if want := []string{"kiosk"}; !reflect.DeepEqual(channels, want) {
+		t.Errorf("Client.Channels returned %v; want %v", channels, want)
+	}
+	for _, text := range []string{"hello", "play album 2"} {
+		if err := sender.SendMessage("kiosk", text); err != nil {
+			t.Fatalf("Client.SendMessage failed: %s", err)
+		}
+	}
+	msgs, err := cli.ReadMessages()
+	if err != nil {
+		t.Fatalf("Client.ReadMessages failed: %s", err)
+	}
+	want := []Message{{"kiosk", "hello"}, {"kiosk", "play album 2"}}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("Client.ReadMessages returned %v; want %v", msgs, want)
+	}
+	if msgs, err := cli.ReadMessages(); err != nil || len(msgs) != 0 {
+		t.Errorf("Client.ReadMessages returned %v, %v; want no messages", msgs, err)
+	}
+
+	if err := cli.Unsubscribe("kiosk"); err != nil {
+		t.Fatalf("Client.Unsubscribe failed: %s", err)
+	}
+	if err := cli.Unsubscribe("kiosk"); err == nil {
+		t.Errorf("Client.Unsubscribe succeeded when not subscribed")
+	}
+	if err := sender.SendMessage("kiosk", "hello"); err == nil {
+		t.Errorf("Client.SendMessage succeeded without subscribers")
+	}
+}
+
+func TestMessageWatcher(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	net, addr := localAddr()
+	w, err := NewMessageWatcher(net, addr, "", []string{"kiosk", "lobby"}, "player")
+	if err != nil {
+		t.Fatalf("NewMessageWatcher failed: %s", err)
+	}
+	defer w.Close()
+
+	want := []Message{{"lobby", "pause"}, {"kiosk", "play"}}
+	for _, m := range want {
+		if err := cli.SendMessage(m.Channel, m.Text); err != nil {
+			t.Fatalf("Client.SendMessage failed: %s", err)
+		}
+	}
+	var msgs []Message
+	for len(msgs) < len(want) {
+		select {
+		case m := <-w.Message:
+			msgs = append(msgs, m)
+		case subsystem := <-w.Event:
+			t.Errorf("unexpected event %q", subsystem)
+		case err := <-w.Error:
+			t.Fatalf("Watcher failed: %s", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received messages %v; want %v", msgs, want)
+		}
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("received messages %v; want %v", msgs, want)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SingleMode is the mode of the single option: when enabled, playback
+// stops after the current song, or repeats it if repeat is enabled.
+type SingleMode int
+
+// Single modes. In SingleOneshot mode, single is disabled once the current
+// song stops.
+const (
+	SingleOff SingleMode = iota
+	SingleOn
+	SingleOneshot
+)
+
+// String returns the argument of the single command for the mode m.
+func (m SingleMode) String() string {
+	return oneshotString(int(m))
+}
+
+// ConsumeMode is the mode of the consume option: when enabled, songs are
+// removed from the queue once played.
+type ConsumeMode int
+
+// Consume modes. In ConsumeOneshot mode, consume is disabled once the
+// current song is removed.
+const (
+	ConsumeOff ConsumeMode = iota
+	ConsumeOn
+	ConsumeOneshot
+)
+
+// String returns the argument of the consume command for the mode m.
+func (m ConsumeMode) String() string {
+	return oneshotString(int(m))
+}
+
+func oneshotString(m int) string {
+	switch m {
+	case 0:
+		return "0"
+	case 1:
+		return "1"
+	case 2:
+		return "oneshot"
+	}
+	return strconv.Itoa(m)
+}
+
+// parseOneshotMode parses the value of the single or consume status.
+func parseOneshotMode(s string) (int, error) {
+	switch s {
+	case "0":
+		return 0, nil
+	case "1":
+		return 1, nil
+	case "oneshot":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("invalid mode %q", s)
+}
+
+// ReplayGain is the replay gain mode of MPD.
+type ReplayGain string
+
+// Replay gain modes. In ReplayGainAuto mode, the album gain is used when
+// playing songs of the same album in order, and the track gain otherwise.
+const (
+	ReplayGainOff   ReplayGain = "off"
+	ReplayGainTrack ReplayGain = "track"
+	ReplayGainAlbum ReplayGain = "album"
+	ReplayGainAuto  ReplayGain = "auto"
+)
+
+// SetSingle sets the mode of the single option. Unlike Single, it supports
+// the oneshot mode.
+func (c *Client) SetSingle(mode SingleMode) error {
+	return c.Command("single %s", Quoted(mode.String())).OK()
+}
+
+// SetConsume sets the mode of the consume option. Unlike Consume, it
+// supports the oneshot mode.
+func (c *Client) SetConsume(mode ConsumeMode) error {
+	return c.Command("consume %s", Quoted(mode.String())).OK()
+}
+
+// Crossfade sets the crossfading between songs to d, rounded to the
+// second. Zero disables crossfading.
+func (c *Client) Crossfade(d time.Duration) error {
+	return c.Command("crossfade %d", roundSeconds(d)).OK()
+}
+
+// MixRampDB sets the threshold, in decibels, at which songs overlap with
+// MixRamp.
+func (c *Client) MixRampDB(db float64) error {
+	return c.Command("mixrampdb %s", Quoted(formatFloat(db))).OK()
+}
+
+// MixRampDelay sets the delay subtracted from the overlap computed with
+// MixRamp. A negative delay disables MixRamp, which then falls back to
+// crossfading.
+func (c *Client) MixRampDelay(d time.Duration) error {
+	return c.Command("mixrampdelay %s", Quoted(mixRampDelayArg(d))).OK()
+}
+
+// ReplayGainMode sets the replay gain mode.
+func (c *Client) ReplayGainMode(mode ReplayGain) error {
+	return c.Command("replay_gain_mode %s", Quoted(string(mode))).OK()
+}
+
+// ReplayGainStatus returns the replay gain mode.
+func (c *Client) ReplayGainStatus() (ReplayGain, error) {
+	attrs, err := c.Command("replay_gain_status").Attrs()
+	if err != nil {
+		return "", err
+	}
+	mode, ok := attrs["replay_gain_mode"]
+	if !ok {
+		return "", protocolError("missing replay_gain_mode", "")
+	}
+	return ReplayGain(mode), nil
+}
+
+// GetVol returns the volume, in the range 0-100, or -1 if MPD has no
+// mixer.
+func (c *Client) GetVol() (int, error) {
+	attrs, err := c.Command("getvol").Attrs()
+	if err != nil {
+		return -1, err
+	}
+	return parseVolume(attrs)
+}
+
+// Volume changes the volume by change, which may be negative. Unlike
+// SetVolume, the command is not sent again if the client reconnects.
+func (c *Client) Volume(change int) error {
+	return c.Command("volume %d", change).OK()
+}
+
+func parseVolume(attrs Attrs) (int, error) {
+	v, ok := attrs["volume"]
+	if !ok {
+		return -1, nil
+	}
+	vol, err := strconv.Atoi(v)
+	if err != nil {
+		return -1, protocolError("can't parse volume", "volume: "+v)
+	}
+	return vol, nil
+}
+
+func roundSeconds(d time.Duration) int64 {
+	return int64((d + time.Second/2) / time.Second)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func mixRampDelayArg(d time.Duration) string {
+	if d < 0 {
+		return "nan"
+	}
+	return formatFloat(d.Seconds())
+}
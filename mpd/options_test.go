@@ -0,0 +1,167 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOneshotModes(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	if err := cli.SetSingle(SingleOneshot); err != nil {
+		t.Fatalf("Client.SetSingle failed: %s", err)
+	}
+	defer cli.SetSingle(SingleOff)
+	if err := cli.SetConsume(ConsumeOn); err != nil {
+		t.Fatalf("Client.SetConsume failed: %s", err)
+	}
+	defer cli.SetConsume(ConsumeOff)
+	status, err := cli.StatusInfo()
+	if err != nil {
+		t.Fatalf("Client.StatusInfo failed: %s", err)
+	}
+	if !status.Single || status.SingleMode != SingleOneshot {
+		t.Errorf("single is %v in mode %v; want true in mode %v", status.Single, status.SingleMode, SingleOneshot)
+	}
+	if !status.Consume || status.ConsumeMode != ConsumeOn {
+		t.Errorf("consume is %v in mode %v; want true in mode %v", status.Consume, status.ConsumeMode, ConsumeOn)
+	}
+}
+
+func TestMixingOptions(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	if err := cli.Crossfade(4600 * time.Millisecond); err != nil {
+		t.Fatalf("Client.Crossfade failed: %s", err)
+	}
+	defer cli.Crossfade(0)
+	if err := cli.MixRampDB(-17.5); err != nil {
+		t.Fatalf("Client.MixRampDB failed: %s", err)
+	}
+	defer cli.MixRampDB(0)
+	if err := cli.MixRampDelay(1500 * time.Millisecond); err != nil {
+		t.Fatalf("Client.MixRampDelay failed: %s", err)
+	}
+	status, err := cli.StatusInfo()
+	if err != nil {
+		t.Fatalf("Client.StatusInfo failed: %s", err)
+	}
+	if status.Crossfade != 5*time.Second || status.MixRampDB != -17.5 || status.MixRampDelay != 1500*time.Millisecond {
+		t.Errorf("status has crossfade %v, mixrampdb %v and mixrampdelay %v; want 5s, -17.5 and 1.5s",
+			status.Crossfade, status.MixRampDB, status.MixRampDelay)
+	}
+	if err := cli.MixRampDelay(-1); err != nil {
+		t.Fatalf("Client.MixRampDelay failed: %s", err)
+	}
+	if status, err = cli.StatusInfo(); err != nil {
+		t.Fatalf("Client.StatusInfo failed: %s", err)
+	}
+	if status.MixRampDelay != 0 {
+		t.Errorf("MixRampDelay is %v after disabling it", status.MixRampDelay)
+	}
+}
+
+func TestReplayGain(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	if err := cli.ReplayGainMode(ReplayGainAlbum); err != nil {
+		t.Fatalf("Client.ReplayGainMode failed: %s", err)
+	}
+	defer cli.ReplayGainMode(ReplayGainOff)
+	mode, err := cli.ReplayGainStatus()
+	if err != nil {
+		t.Fatalf("Client.ReplayGainStatus failed: %s", err)
+	}
+	if mode != ReplayGainAlbum {
+		t.Errorf("Client.ReplayGainStatus returned %q; want %q", mode, ReplayGainAlbum)
+	}
+	if err := cli.ReplayGainMode("loud"); err == nil {
+		t.Errorf("Client.ReplayGainMode succeeded with an invalid mode")
+	}
+
+	pcli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0", "OK\n")))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", pcli, err)
+	}
+	defer pcli.Close()
+	var pe *ProtocolError
+	if _, err := pcli.ReplayGainStatus(); !errors.As(err, &pe) {
+		t.Errorf("Client.ReplayGainStatus without replay_gain_mode returned %v; want a ProtocolError", err)
+	}
+}
+
+func TestVolume(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	if err := cli.SetVolume(50); err != nil {
+		t.Fatalf("Client.SetVolume failed: %s", err)
+	}
+	for _, tc := range []struct{ change, want int }{{10, 60}, {-15, 45}, {-100, 0}} {
+		if err := cli.Volume(tc.change); err != nil {
+			t.Fatalf("Client.Volume failed: %s", err)
+		}
+		vol, err := cli.GetVol()
+		if err != nil {
+			t.Fatalf("Client.GetVol failed: %s", err)
+		}
+		if vol != tc.want {
+			t.Errorf("volume is %d after changing it by %d; want %d", vol, tc.change, tc.want)
+		}
+	}
+	if err := cli.SetVolume(50); err != nil {
+		t.Fatalf("Client.SetVolume failed: %s", err)
+	}
+}
+
+func TestOptionsCommandList(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	cl := cli.BeginCommandList()
+	cl.SetSingle(SingleOn)
+	cl.SetConsume(ConsumeOneshot)
+	cl.Crossfade(2 * time.Second)
+	cl.MixRampDB(-10)
+	cl.MixRampDelay(-1)
+	cl.ReplayGainMode(ReplayGainAuto)
+	cl.SetVolume(20)
+	cl.Volume(5)
+	pr := cl.ReplayGainStatus()
+	pv := cl.GetVol()
+	ps := cl.Status()
+	cl.SetSingle(SingleOff)
+	cl.SetConsume(ConsumeOff)
+	cl.Crossfade(0)
+	cl.MixRampDB(0)
+	cl.ReplayGainMode(ReplayGainOff)
+	cl.SetVolume(50)
+	if err := cl.End(); err != nil {
+		t.Fatalf("CommandList.End failed: %s", err)
+	}
+	if mode, err := pr.Value(); err != nil || mode != ReplayGainAuto {
+		t.Errorf("replay gain status is %q, %v; want mode %q", mode, err, ReplayGainAuto)
+	}
+	if vol, err := pv.Value(); err != nil || vol != 25 {
+		t.Errorf("getvol returned %d, %v; want volume 25", vol, err)
+	}
+	a, err := ps.Value()
+	if err != nil {
+		t.Fatalf("PromisedAttrs.Value failed: %s", err)
+	}
+	status, err := ParseStatus(a)
+	if err != nil {
+		t.Fatalf("ParseStatus failed: %s", err)
+	}
+	if status.SingleMode != SingleOn || status.ConsumeMode != ConsumeOneshot || status.Crossfade != 2*time.Second || status.MixRampDB != -10 {
+		t.Errorf("status is %+v; want single on, consume oneshot, crossfade 2s and mixrampdb -10", status)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import "context"
+
+// PartitionClient is a Client whose requests are always run in a given
+// partition, returned by Client.InPartition.
+type PartitionClient struct {
+	*Client
+}
+
+// InPartition returns a client that shares the connection with c, but whose
+// requests are run in the partition name: the connection switches to this
+// partition before each request if it isn't already selected, including
+// after the client reconnects. The partition must exist.
+//
+// Several PartitionClients for different partitions can share a
+// connection. The requests of c keep running in the partition chosen with
+// Partition or WithPartition, and only that partition is selected again
+// when the client reconnects.
+func (c *Client) InPartition(name string) *PartitionClient {
+	c2 := *c
+	c2.inPartition = name
+	return &PartitionClient{&c2}
+}
+
+// Name returns the name of the partition of pc.
+func (pc *PartitionClient) Name() string {
+	return pc.inPartition
+}
+
+// WithContext is like Client.WithContext, but returns a PartitionClient
+// bound to the same partition.
+func (pc *PartitionClient) WithContext(ctx context.Context) *PartitionClient {
+	return &PartitionClient{pc.Client.WithContext(ctx)}
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func statusPartition(t *testing.T, cli *Client) string {
+	t.Helper()
+	status, err := cli.Status()
+	if err != nil {
+		t.Fatalf("Client.Status failed: %s", err)
+	}
+	return status["partition"]
+}
+
+func TestInPartition(t *testing.T) {
+	cli := localDial(t, WithReconnect(3, 10*time.Millisecond))
+	defer teardown(cli, t)
+
+	cli.NewPartition("kitchen") // may already exist
+	kitchen := cli.InPartition("kitchen")
+	living := cli.InPartition("default")
+	if kitchen.Name() != "kitchen" {
+		t.Errorf("PartitionClient.Name is %q; want %q", kitchen.Name(), "kitchen")
+	}
+	for _, pc := range []*PartitionClient{kitchen, living, kitchen, kitchen.WithContext(context.Background())} {
+		if p := statusPartition(t, pc.Client); p != pc.Name() {
+			t.Errorf("status of %s client is in partition %q", pc.Name(), p)
+		}
+	}
+	if p := statusPartition(t, cli); p != "default" {
+		t.Errorf("status is in partition %q; want default", p)
+	}
+
+	// The partition chosen by Partition is not changed by the requests of
+	// the partition clients.
+	if err := cli.Partition("kitchen"); err != nil {
+		t.Fatalf("Client.Partition failed: %s", err)
+	}
+	if p := statusPartition(t, living.Client); p != "default" {
+		t.Errorf("status of default client is in partition %q", p)
+	}
+	if p := statusPartition(t, cli); p != "kitchen" {
+		t.Errorf("status is in partition %q; want kitchen", p)
+	}
+	if err := cli.Partition("default"); err != nil {
+		t.Fatalf("Client.Partition failed: %s", err)
+	}
+
+	// Only the chosen partition is selected again after reconnecting.
+	if p := statusPartition(t, kitchen.Client); p != "kitchen" {
+		t.Errorf("status of kitchen client is in partition %q", p)
+	}
+	dropConn(t, cli)
+	if p := statusPartition(t, cli); p != "default" {
+		t.Errorf("status after reconnecting is in partition %q; want default", p)
+	}
+	if p := statusPartition(t, kitchen.Client); p != "kitchen" {
+		t.Errorf("status of kitchen client after reconnecting is in partition %q", p)
+	}
+
+	if err := cli.InPartition("missing").Ping(); err == nil {
+		t.Errorf("request in a missing partition succeeded")
+	}
+}
+
+func TestPartitions(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	cli.NewPartition("kitchen") // may already exist
+	partitions, err := cli.Partitions()
+	if err != nil {
+		t.Fatalf("Client.Partitions failed: %s", err)
+	}
+	found := make(map[string]bool)
+	for _, p := range partitions {
+		found[p.Name] = true
+	}
+	if !found["default"] || !found["kitchen"] {
+		t.Errorf("Client.Partitions returned %v; want default and kitchen", partitions)
+	}
+}
+
+func TestPartitionWatcher(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	cli.NewPartition("kitchen") // may already exist
+	net, addr := localAddr()
+	if _, err := NewPartitionWatcher(net, addr, "", "missing", "player"); err == nil {
+		t.Errorf("NewPartitionWatcher succeeded with a missing partition")
+	}
+	w, err := NewPartitionWatcher(net, addr, "", "kitchen", "player")
+	if err != nil {
+		t.Fatalf("NewPartitionWatcher failed: %s", err)
+	}
+	defer w.Close()
+
+	// A change in another partition is not reported.
+	if err := cli.Stop(); err != nil {
+		t.Fatalf("Client.Stop failed: %s", err)
+	}
+	select {
+	case subsystem := <-w.Event:
+		t.Fatalf("received event %q of the default partition", subsystem)
+	case err := <-w.Error:
+		t.Fatalf("Watcher failed: %s", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	kitchen := cli.InPartition("kitchen")
+	if err := kitchen.Stop(); err != nil {
+		t.Fatalf("Client.Stop failed: %s", err)
+	}
+	select {
+	case subsystem := <-w.Event:
+		if subsystem != "player" {
+			t.Errorf("received event %q; want player", subsystem)
+		}
+	case err := <-w.Error:
+		t.Fatalf("Watcher failed: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event received")
+	}
+}
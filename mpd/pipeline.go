@@ -0,0 +1,133 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import "errors"
+
+// Pipeline is a batch of independent commands. Unlike a CommandList, the
+// commands are not wrapped in a command list: they are sent back-to-back,
+// and MPD runs each one even if a previous one failed. The responses are
+// read in order while they are sent, so the batch only takes one round
+// trip.
+//
+// Commands are added with the methods of CommandList, and results are
+// promised in the same way.
+//
+//	p := c.BeginPipeline()
+//	status := p.Status()
+//	song := p.CurrentSong()
+//	errs, err := p.End()
+type Pipeline struct {
+	CommandList
+}
+
+// BeginPipeline creates a new Pipeline using this connection.
+func (c *Client) BeginPipeline() *Pipeline {
+	return &Pipeline{CommandList{client: c}}
+}
+
+// End sends the commands of the pipeline and reads their responses. errs
+// holds the error of each command, in order, which is nil if the command
+// succeeded. The promises of the commands that failed are not fulfilled.
+//
+// err is not nil if the responses could not all be read, e.g. because the
+// connection broke. The commands whose response was not read then fail with
+// err.
+func (p *Pipeline) End() (errs []error, err error) {
+	retry := true
+	for i := range p.cmds {
+		retry = retry && idempotent(p.cmds[i].cmd)
+	}
+	err = p.client.run(retry, func() error {
+		errs, err = p.end()
+		return err
+	})
+	if err != nil && errs == nil {
+		// No response was read.
+		errs = make([]error, len(p.cmds))
+		fill(errs, err)
+	}
+	return errs, err
+}
+
+func (p *Pipeline) end() ([]error, error) {
+	c := p.client
+	// The commands are sent while the responses are read: neither side
+	// can wait for the whole batch, as MPD answers each command before
+	// reading the next one and drops clients whose output buffer is full.
+	text := c.text
+	ids := make(chan uint, len(p.cmds))
+	sent := make(chan error, 1)
+	go func() {
+		defer close(ids)
+		for i := range p.cmds {
+			id, err := request(text, "%v", p.cmds[i].cmd)
+			if err != nil {
+				// Unblock the pending read.
+				text.Close()
+				sent <- err
+				return
+			}
+			ids <- id
+		}
+	}()
+	errs, err := p.readResponses(ids, sent)
+	if err != nil {
+		// The other responses are lost: stop sending commands.
+		c.abort()
+	}
+	for range ids {
+		// Wait for the sender to return.
+	}
+	return errs, err
+}
+
+// readResponses reads the response of each command of the pipeline, whose
+// request ids are received from ids. If a command can't be sent, ids is
+// closed and the error is received from sent.
+func (p *Pipeline) readResponses(ids <-chan uint, sent <-chan error) ([]error, error) {
+	c := p.client
+	errs := make([]error, len(p.cmds))
+	for i := range p.cmds {
+		id, ok := <-ids
+		if !ok {
+			err := <-sent
+			fill(errs[i:], err)
+			return errs, err
+		}
+		c.text.StartResponse(id)
+		// The response of each command ends with OK or ACK.
+		c.inResponse = true
+		err := withCommand(p.readResponse(p.cmds[i].promise, "OK"), p.cmds[i].cmd)
+		c.text.EndResponse(id)
+		var (
+			mpdErr Error
+			pe     *ProtocolError
+		)
+		switch {
+		case err == nil:
+		case errors.As(err, &mpdErr):
+			errs[i] = err
+		case errors.As(err, &pe):
+			errs[i] = c.resync(err)
+			if c.text == nil {
+				// The connection was closed: the other responses
+				// are lost.
+				fill(errs[i+1:], ErrClosed)
+				return errs, ErrClosed
+			}
+		default:
+			fill(errs[i:], err)
+			return errs, err
+		}
+	}
+	return errs, nil
+}
+
+func fill(errs []error, err error) {
+	for i := range errs {
+		errs[i] = err
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	p := cli.BeginPipeline()
+	status := p.Status()
+	p.SetVolume(500)
+	id := p.AddID("song0003.ogg", -1)
+	current := p.CurrentSong()
+	p.Clear()
+	errs, err := p.End()
+	if err != nil {
+		t.Fatalf("Pipeline.End failed: %s", err)
+	}
+	if len(errs) != 5 {
+		t.Fatalf("Pipeline.End returned %d errors; want 5", len(errs))
+	}
+	for i, err := range errs {
+		if i == 1 {
+			if !errors.Is(err, ErrArg) {
+				t.Errorf("setvol failed with %v; want %v", err, ErrArg)
+			}
+		} else if err != nil {
+			t.Errorf("command %d failed: %s", i, err)
+		}
+	}
+	if a, err := status.Value(); err != nil || a["partition"] == "" {
+		t.Errorf("status returned %v, %v", a, err)
+	}
+	if _, err := id.Value(); err != nil {
+		t.Errorf("addid returned %v", err)
+	}
+	if _, err := current.Value(); err != nil {
+		t.Errorf("currentsong returned %v", err)
+	}
+}
+
+func TestPipelineProtocolError(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	inject(t, cli, "bogus", "volume: 10")
+	p := cli.BeginPipeline()
+	status := p.Status()
+	p.Ping()
+	song := p.CurrentSong()
+	errs, err := p.End()
+	if err != nil {
+		t.Fatalf("Pipeline.End failed: %s", err)
+	}
+	var pe *ProtocolError
+	if !errors.As(errs[0], &pe) || pe.Command != "status" {
+		t.Errorf("status failed with %v; want a ProtocolError", errs[0])
+	}
+	if errs[1] != nil || errs[2] != nil {
+		t.Errorf("commands after the protocol error failed with %v", errs[1:])
+	}
+	if _, err := status.Value(); err == nil {
+		t.Errorf("promise of the failed command is fulfilled")
+	}
+	if _, err := song.Value(); err != nil {
+		t.Errorf("currentsong returned %v", err)
+	}
+}
+
+func TestPipelineConnectionLost(t *testing.T) {
+	// The server answers the first command and then stops answering.
+	cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0", "OK\n")), WithReadTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+	}
+	defer cli.Close()
+
+	p := cli.BeginPipeline()
+	p.Ping()
+	p.Ping()
+	p.Ping()
+	errs, err := p.End()
+	if !IsConnectionError(err) {
+		t.Fatalf("Pipeline.End returned %v; want a connection error", err)
+	}
+	if errs[0] != nil || errs[1] != err || errs[2] != err {
+		t.Errorf("Pipeline.End returned %v; want the first ping to succeed", errs)
+	}
+}
+
+func TestPipelineStrictServer(t *testing.T) {
+	// The server answers each command before reading the next one.
+	cli, err := DialWithOptions("pipe", "", WithDialContext(pipeDial("OK MPD 0.23.0", "OK\n", "volume: 10\nOK\n", "OK\n")))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+	}
+	defer cli.Close()
+
+	p := cli.BeginPipeline()
+	p.Ping()
+	status := p.Status()
+	p.Ping()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		errs, err := p.End()
+		if err != nil {
+			t.Errorf("Pipeline.End failed: %s", err)
+			return
+		}
+		for i, err := range errs {
+			if err != nil {
+				t.Errorf("command %d failed: %s", i, err)
+			}
+		}
+		if a, err := status.Value(); err != nil || a["volume"] != "10" {
+			t.Errorf("status returned %v, %v", a, err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Pipeline.End hangs with a server answering each command in turn")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Pool is a pool of connections to MPD. It lets several goroutines send
+// requests in parallel, instead of waiting for each other on a single
+// Client. A Pool is safe for concurrent use by multiple goroutines.
+//
+// Connections are handed out by Get and must be given back with Put, or
+// used through Do. Before a connection is handed out, it is checked with
+// Ping and replaced if it's broken. When it's given back, the partition it
+// was dialed with (see WithPartition) is selected again and all the tag
+// types are enabled, so that switching partitions or tag types doesn't
+// affect later users of the connection.
+type Pool struct {
+	network string
+	addr    string
+	opts    dialOptions
+	slots   chan struct{} // one for each connection handed out
+
+	mu     sync.Mutex // guards idle and closed
+	idle   []*Client
+	closed bool
+}
+
+// ErrPoolClosed is returned by Get when the pool is closed.
+var ErrPoolClosed = errors.New("pool closed")
+
+// NewPool returns a pool of at most size connections to MPD listening on
+// address addr (e.g. "127.0.0.1:6600") on network network (e.g. "tcp").
+// Connections are established as needed, configured by opts. NewPool
+// panics if size is less than 1.
+func NewPool(network, addr string, size int, opts ...DialOption) *Pool {
+	if size < 1 {
+		panic("mpd: pool size must be at least 1")
+	}
+	p := &Pool{
+		network: network,
+		addr:    addr,
+		slots:   make(chan struct{}, size),
+	}
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+	return p
+}
+
+// Get returns a connection from the pool, establishing a new one if none
+// is available. If the pool already handed out all of its connections, Get
+// waits until one is given back or ctx is done.
+func (p *Pool) Get(ctx context.Context) (*Client, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	c, err := p.get(ctx)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return c, nil
+}
+
+func (p *Pool) get(ctx context.Context) (*Client, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+		if len(p.idle) == 0 {
+			p.mu.Unlock()
+			return dialContext(ctx, p.network, p.addr, p.opts)
+		}
+		c := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.mu.Unlock()
+
+		err := c.WithContext(ctx).Ping()
+		if err == nil {
+			return c, nil
+		}
+		c.Close()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// resetTimeout bounds the time Put spends resetting a connection when the
+// pool has no read timeout.
+const resetTimeout = 5 * time.Second
+
+// Put gives back a connection obtained from Get. Broken connections, and
+// connections that can't be reset within the read timeout of the pool (see
+// WithReadTimeout) or five seconds if it has none, are closed instead of
+// being reused, and so are connections still held by a SongIterator or a
+// request in progress. The connection must not be used after it has been
+// given back.
+func (p *Pool) Put(c *Client) {
+	defer func() { <-p.slots }()
+
+	c = &Client{clientConn: c.clientConn} // drop the context and partition
+	d := p.opts.readTimeout
+	if d <= 0 {
+		d = resetTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	if err := p.reset(c.WithContext(ctx)); err != nil {
+		c.Close()
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+}
+
+// reset restores the state a connection had when it was established.
+func (p *Pool) reset(c *Client) error {
+	partition, tagTypes, err := c.resetState()
+	if err != nil {
+		return err
+	}
+	if want := partitionName(p.opts.partition); partitionName(partition) != want {
+		if err := c.Partition(want); err != nil {
+			return err
+		}
+	}
+	if tagTypes {
+		return c.TagTypesAll()
+	}
+	return nil
+}
+
+// Do calls f with a connection from the pool bound to ctx, and gives the
+// connection back once f returns. It returns the error returned by f.
+func (p *Pool) Do(ctx context.Context, f func(*Client) error) error {
+	c, err := p.Get(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Put(c)
+	return f(c.WithContext(ctx))
+}
+
+// partitionName returns the name of the partition selected by name, where an
+// empty name stands for the partition a new connection starts in.
+func partitionName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// Close closes the connections that are not in use. Connections in use are
+// closed when they are given back.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	var err error
+	for _, c := range idle {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
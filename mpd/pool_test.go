@@ -0,0 +1,228 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func localPool(t *testing.T, size int, opts ...DialOption) *Pool {
+	t.Helper()
+	network, addr := localServer(t)
+	return NewPool(network, addr, size, opts...)
+}
+
+func TestPoolDo(t *testing.T) {
+	p := localPool(t, 3)
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	errc := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errc <- p.Do(context.Background(), func(c *Client) error {
+				ls, err := c.ListInfo("foo")
+				if err == nil && len(ls) != 103 {
+					err = fmt.Errorf("ListInfo returned %d entries; want 103", len(ls))
+				}
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := len(p.idle); n > 3 {
+		t.Errorf("pool has %d idle connections; want at most 3", n)
+	}
+}
+
+func TestPoolGetWaits(t *testing.T) {
+	p := localPool(t, 1)
+	defer p.Close()
+
+	c, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Pool.Get failed: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Pool.Get on exhausted pool = %v; want %v", err, context.DeadlineExceeded)
+	}
+	p.Put(c)
+	c2, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Pool.Get failed: %s", err)
+	}
+	if c2.clientConn != c.clientConn {
+		t.Errorf("Pool.Get did not reuse the connection")
+	}
+	p.Put(c2)
+}
+
+func TestPoolEvictsBrokenConnections(t *testing.T) {
+	p := localPool(t, 1)
+	defer p.Close()
+
+	c, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Pool.Get failed: %s", err)
+	}
+	dropConn(t, c)
+	p.Put(c)
+
+	c2, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Pool.Get failed: %s", err)
+	}
+	defer p.Put(c2)
+	if c2.clientConn == c.clientConn {
+		t.Errorf("Pool.Get returned a broken connection")
+	}
+	if err := c2.Ping(); err != nil {
+		t.Errorf("Client.Ping failed: %s", err)
+	}
+}
+
+func TestPoolRestoresPartition(t *testing.T) {
+	c := localDial(t)
+	c.NewPartition("pool") // may already exist
+	c.Close()
+
+	p := localPool(t, 1, WithPartition("pool"))
+	defer p.Close()
+
+	err := p.Do(context.Background(), func(c *Client) error {
+		return c.Partition("default")
+	})
+	if err != nil {
+		t.Fatalf("Pool.Do failed: %s", err)
+	}
+	err = p.Do(context.Background(), func(c *Client) error {
+		status, err := c.Status()
+		if err == nil && status["partition"] != "pool" {
+			err = fmt.Errorf("connection is in partition %q; want %q", status["partition"], "pool")
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	p := localPool(t, 1)
+	if err := p.Do(context.Background(), func(c *Client) error { return c.Ping() }); err != nil {
+		t.Fatalf("Pool.Do failed: %s", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Pool.Close failed: %s", err)
+	}
+	if _, err := p.Get(context.Background()); err != ErrPoolClosed {
+		t.Fatalf("Pool.Get on closed pool = %v; want %v", err, ErrPoolClosed)
+	}
+}
+
+func TestPoolRestoresTagTypes(t *testing.T) {
+	p := localPool(t, 1)
+	defer p.Close()
+
+	err := p.Do(context.Background(), func(c *Client) error {
+		return c.TagTypesClear()
+	})
+	if err != nil {
+		t.Fatalf("Pool.Do failed: %s", err)
+	}
+	cli := localDial(t)
+	all, err := cli.TagTypes()
+	cli.Close()
+	if err != nil {
+		t.Fatalf("Client.TagTypes failed: %s", err)
+	}
+	err = p.Do(context.Background(), func(c *Client) error {
+		tags, err := c.TagTypes()
+		if err == nil && !reflect.DeepEqual(tags, all) {
+			err = fmt.Errorf("connection has tag types %v; want %v", tags, all)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolResetTimeout(t *testing.T) {
+	// The server answers the greeting and the ping of Get, then stalls.
+	p := NewPool("pipe", "", 1, WithDialContext(pipeDial("OK MPD 0.23.0", "OK\n")), WithPartition(""), WithReadTimeout(50*time.Millisecond))
+	defer p.Close()
+
+	c, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Pool.Get failed: %s", err)
+	}
+	c.partition = "other"
+	done := make(chan struct{})
+	go func() {
+		p.Put(c)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Pool.Put hangs on a stalled server")
+	}
+	if len(p.idle) != 0 {
+		t.Errorf("Pool.Put kept a connection that could not be reset")
+	}
+}
+
+func TestNewPoolSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewPool with size 0 did not panic")
+		}
+	}()
+	NewPool("tcp", "127.0.0.1:6600", 0)
+}
+
+func TestPoolPutWithOpenIterator(t *testing.T) {
+	p := localPool(t, 1, WithReadTimeout(100*time.Millisecond))
+	defer p.Close()
+
+	c, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Pool.Get failed: %s", err)
+	}
+	it, err := c.Command("listallinfo foo").SongIter()
+	if err != nil {
+		t.Fatalf("SongIter failed: %s", err)
+	}
+	defer it.Close()
+	done := make(chan struct{})
+	go func() {
+		p.Put(c)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Pool.Put hangs while a SongIterator holds the connection")
+	}
+	if len(p.idle) != 0 {
+		t.Errorf("Pool.Put kept a connection held by a SongIterator")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PlaylistID returns the song with identifier id in the queue.
+func (c *Client) PlaylistID(id int) (*Song, error) {
+	songs, err := c.Command("playlistid %d", id).Songs()
+	if err != nil {
+		return nil, err
+	}
+	if len(songs) == 0 {
+		return nil, protocolError("no song returned for id "+strconv.Itoa(id), "")
+	}
+	return &songs[0], nil
+}
+
+// PlaylistFind returns the songs in the queue matching filter. Unlike Find,
+// the songs include their position and identifier in the queue.
+func (c *Client) PlaylistFind(filter Filter) ([]Song, error) {
+	return c.Command("playlistfind %s", filter.arg()).Songs()
+}
+
+// PlaylistSearch is like PlaylistFind, but the search is not case sensitive.
+func (c *Client) PlaylistSearch(filter Filter) ([]Song, error) {
+	return c.Command("playlistsearch %s", filter.arg()).Songs()
+}
+
+// PlChanges returns the songs in the queue that changed since the queue
+// version version, as reported in the "playlist" attribute of Status. If
+// start and end are non-negative, only the changes in the range of
+// positions [start, end) are returned.
+//
+// Songs removed from the end of the queue are not reported: the new length
+// of the queue is the "playlistlength" attribute of Status.
+func (c *Client) PlChanges(version, start, end int) ([]Song, error) {
+	if start < 0 || end < 0 {
+		return c.Command("plchanges %d", version).Songs()
+	}
+	if start > end {
+		return nil, fmt.Errorf("invalid range: %d:%d", start, end)
+	}
+	return c.Command("plchanges %d %d:%d", version, start, end).Songs()
+}
+
+// PosID is the position and identifier of a song in the queue.
+type PosID struct {
+	Pos int
+	ID  int
+}
+
+// PlChangesPosID is like PlChanges, but only returns the positions and
+// identifiers of the songs that changed, which is cheaper.
+func (c *Client) PlChangesPosID(version int) (changes []PosID, err error) {
+	err = c.Command("plchangesposid %d", version).exec(func() error {
+		changes, err = c.readPosIDs()
+		return err
+	})
+	return
+}
+
+// readPosIDs reads a list of cpos/Id pairs.
+func (c *Client) readPosIDs() ([]PosID, error) {
+	changes := []PosID{}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "OK" {
+			break
+		}
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			return nil, protocolError("can't parse line", line)
+		}
+		key, value := line[:i], line[i+2:]
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, protocolError("can't parse "+key, line)
+		}
+		switch key {
+		case "cpos":
+			changes = append(changes, PosID{Pos: n, ID: -1})
+		case "Id":
+			if len(changes) == 0 {
+				return nil, protocolError("unexpected line", line)
+			}
+			changes[len(changes)-1].ID = n
+		}
+	}
+	return changes, nil
+}
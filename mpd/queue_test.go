@@ -0,0 +1,131 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// fillQueue replaces the queue with the songs matching filter and returns
+// the queue version.
+func fillQueue(t *testing.T, cli *Client, filter Filter) int {
+	t.Helper()
+	if err := cli.Clear(); err != nil {
+		t.Fatalf("Client.Clear failed: %s", err)
+	}
+	if err := cli.FindAdd(filter, nil, -1); err != nil {
+		t.Fatalf("Client.FindAdd failed: %s", err)
+	}
+	return queueVersion(t, cli)
+}
+
+func queueVersion(t *testing.T, cli *Client) int {
+	t.Helper()
+	status, err := cli.Status()
+	if err != nil {
+		t.Fatalf("Client.Status failed: %s", err)
+	}
+	version, err := strconv.Atoi(status["playlist"])
+	if err != nil {
+		t.Fatalf("invalid playlist version %q", status["playlist"])
+	}
+	return version
+}
+
+func TestPlaylistID(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	fillQueue(t, cli, Eq(TagAlbum, "Album 4"))
+	queue, err := cli.PlaylistSongs(-1, -1)
+	if err != nil {
+		t.Fatalf("Client.PlaylistSongs failed: %s", err)
+	}
+	song, err := cli.PlaylistID(queue[3].ID)
+	if err != nil {
+		t.Fatalf("Client.PlaylistID failed: %s", err)
+	}
+	if !reflect.DeepEqual(*song, queue[3]) {
+		t.Errorf("Client.PlaylistID returned %+v; want %+v", *song, queue[3])
+	}
+	if _, err := cli.PlaylistID(-2); err == nil {
+		t.Errorf("Client.PlaylistID succeeded for a missing song")
+	}
+}
+
+func TestPlaylistFind(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	fillQueue(t, cli, Eq(TagAlbum, "Album 4"))
+	songs, err := cli.PlaylistFind(Eq(TagGenre, "Pop"))
+	if err != nil {
+		t.Fatalf("Client.PlaylistFind failed: %s", err)
+	}
+	var pos []int
+	for _, s := range songs {
+		pos = append(pos, s.Pos)
+	}
+	if want := []int{2, 5, 8}; !reflect.DeepEqual(pos, want) {
+		t.Errorf("Client.PlaylistFind returned songs at %v; want %v", pos, want)
+	}
+	songs, err = cli.PlaylistSearch(Eq(TagTitle, "title 47"))
+	if err != nil {
+		t.Fatalf("Client.PlaylistSearch failed: %s", err)
+	}
+	if len(songs) != 1 || songs[0].Pos != 7 {
+		t.Errorf("Client.PlaylistSearch returned %+v; want the song at 7", songs)
+	}
+}
+
+func TestPlChanges(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	version := fillQueue(t, cli, Eq(TagAlbum, "Album 4"))
+	changes, err := cli.PlChangesPosID(version)
+	if err != nil {
+		t.Fatalf("Client.PlChangesPosID failed: %s", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Client.PlChangesPosID returned %v; want no changes", changes)
+	}
+
+	if err := cli.Delete(7, -1); err != nil {
+		t.Fatalf("Client.Delete failed: %s", err)
+	}
+	changes, err = cli.PlChangesPosID(version)
+	if err != nil {
+		t.Fatalf("Client.PlChangesPosID failed: %s", err)
+	}
+	queue, err := cli.PlaylistSongs(-1, -1)
+	if err != nil {
+		t.Fatalf("Client.PlaylistSongs failed: %s", err)
+	}
+	want := []PosID{{7, queue[7].ID}, {8, queue[8].ID}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Client.PlChangesPosID returned %v; want %v", changes, want)
+	}
+
+	songs, err := cli.PlChanges(version, -1, -1)
+	if err != nil {
+		t.Fatalf("Client.PlChanges failed: %s", err)
+	}
+	if !reflect.DeepEqual(songs, queue[7:]) {
+		t.Errorf("Client.PlChanges returned %+v; want %+v", songs, queue[7:])
+	}
+	songs, err = cli.PlChanges(version, 8, 20)
+	if err != nil {
+		t.Fatalf("Client.PlChanges failed: %s", err)
+	}
+	if len(songs) != 1 || songs[0].URI != "song0049.ogg" {
+		t.Errorf("Client.PlChanges in window returned %+v; want song0049.ogg", songs)
+	}
+	if _, err := cli.PlChanges(version, 2, 1); err == nil {
+		t.Errorf("Client.PlChanges succeeded with invalid range")
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var errInconsistentQueue = errors.New("inconsistent queue changes")
+
+// QueueChange describes how the queue changed, as reported by QueueMirror.
+type QueueChange struct {
+	Version  int         // new version of the queue
+	Inserted []Song      // songs added to the queue, at their new position
+	Removed  []Song      // songs removed from the queue, at their old position
+	Moved    []QueueMove // songs moved within the queue
+	Updated  []Song      // songs that changed, e.g. their priority, and may also have moved
+}
+
+func (qc *QueueChange) empty() bool {
+	return len(qc.Inserted) == 0 && len(qc.Removed) == 0 && len(qc.Moved) == 0 && len(qc.Updated) == 0
+}
+
+// QueueMove describes a song that was moved within the queue.
+type QueueMove struct {
+	ID       int
+	From, To int
+}
+
+// QueueMirror keeps a local copy of the queue (the current playlist) of
+// MPD. It watches the playlist subsystem, and updates the copy
+// incrementally using the plchangesposid command when the queue changes.
+//
+// Each update is reported on the Change channel, and errors on the Error
+// channel. Both channels must be received from, otherwise updates stall.
+type QueueMirror struct {
+	conn    *Client  // client connection to MPD
+	watcher *Watcher // watches for changes of the queue
+	exit    chan bool
+	done    chan bool
+
+	mu      sync.Mutex // guards version and songs
+	version int
+	songs   []Song
+
+	Change chan QueueChange // change channel
+	Error  chan error       // error channel
+}
+
+// NewQueueMirror connects to MPD server, loads the queue, and starts
+// keeping it up to date.
+func NewQueueMirror(net, addr, passwd string) (*QueueMirror, error) {
+	conn, err := DialWithOptions(net, addr, WithPassword(passwd))
+	if err != nil {
+		return nil, err
+	}
+	// Start watching before loading, so that no change is missed.
+	w, err := NewWatcher(net, addr, passwd, "playlist")
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	m := &QueueMirror{
+		conn:    conn,
+		watcher: w,
+		exit:    make(chan bool),
+		done:    make(chan bool),
+		Change:  make(chan QueueChange),
+		Error:   make(chan error),
+	}
+	if _, err := m.reload(); err != nil {
+		w.Close()
+		conn.Close()
+		return nil, err
+	}
+	go m.loop()
+	return m, nil
+}
+
+// Snapshot returns a copy of the queue. The songs must not be modified.
+func (m *QueueMirror) Snapshot() []Song {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Song(nil), m.songs...)
+}
+
+// Version returns the version of the queue returned by Snapshot.
+func (m *QueueMirror) Version() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.version
+}
+
+// Close stops updating the queue, closes the Change and Error channels, and
+// the connections to MPD server.
+func (m *QueueMirror) Close() error {
+	close(m.exit)
+	err := m.watcher.Close()
+	<-m.done
+	if cerr := m.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (m *QueueMirror) loop() {
+	defer func() {
+		close(m.Change)
+		close(m.Error)
+		close(m.done)
+	}()
+	for {
+		select {
+		case _, ok := <-m.watcher.Event:
+			if !ok {
+				return
+			}
+			qc, err := m.update()
+			if err != nil {
+				// The queue may have changed while it was being
+				// updated; start over.
+				qc, err = m.reload()
+			}
+			switch {
+			case err != nil:
+				if !m.sendError(err) {
+					return
+				}
+			case !qc.empty():
+				select {
+				case m.Change <- *qc:
+				case <-m.exit:
+					return
+				}
+			}
+		case err, ok := <-m.watcher.Error:
+			if !ok || !m.sendError(err) {
+				return
+			}
+		case <-m.exit:
+			return
+		}
+	}
+}
+
+func (m *QueueMirror) sendError(err error) bool {
+	select {
+	case m.Error <- err:
+		return true
+	case <-m.exit:
+		return false
+	}
+}
+
+// queueStatus returns the version and length of the queue.
+func (m *QueueMirror) queueStatus() (version, length int, err error) {
+	status, err := m.conn.StatusInfo()
+	if err != nil {
+		return 0, 0, err
+	}
+	return status.Playlist, status.PlaylistLength, nil
+}
+
+// reload loads the whole queue.
+func (m *QueueMirror) reload() (*QueueChange, error) {
+	for {
+		version, _, err := m.queueStatus()
+		if err != nil {
+			return nil, err
+		}
+		songs, err := m.conn.PlaylistSongs(-1, -1)
+		if err != nil {
+			return nil, err
+		}
+		v, _, err := m.queueStatus()
+		if err != nil {
+			return nil, err
+		}
+		if v == version {
+			return m.set(version, songs, nil), nil
+		}
+	}
+}
+
+// update updates the queue with the changes since the current version.
+func (m *QueueMirror) update() (*QueueChange, error) {
+	m.mu.Lock()
+	old, oldVersion := m.songs, m.version
+	m.mu.Unlock()
+
+	oldPos := make(map[int]int, len(old))
+	for i := range old {
+		oldPos[old[i].ID] = i
+	}
+	for {
+		version, length, err := m.queueStatus()
+		if err != nil {
+			return nil, err
+		}
+		if version == oldVersion {
+			return &QueueChange{Version: version}, nil
+		}
+		changes, err := m.conn.PlChangesPosID(oldVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		// Positions that didn't change keep their song. The songs at
+		// the other positions are fetched, as a song may have both
+		// moved and changed.
+		ids := make([]int, length)
+		for i := range ids {
+			ids[i] = -1
+			if i < len(old) {
+				ids[i] = old[i].ID
+			}
+		}
+		fetch := make(map[int]bool)
+		lo, hi := length, -1
+		for _, c := range changes {
+			if c.Pos >= length {
+				continue
+			}
+			ids[c.Pos] = c.ID
+			fetch[c.ID] = true
+			if c.Pos < lo {
+				lo = c.Pos
+			}
+			if c.Pos > hi {
+				hi = c.Pos
+			}
+		}
+		fetched := make(map[int]Song, len(fetch))
+		if hi >= lo {
+			songs, err := m.conn.PlChanges(oldVersion, lo, hi+1)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range songs {
+				if fetch[s.ID] {
+					fetched[s.ID] = s
+				}
+			}
+		}
+
+		if v, _, err := m.queueStatus(); err != nil {
+			return nil, err
+		} else if v != version {
+			continue
+		}
+		songs := make([]Song, length)
+		var updated []Song
+		for i, id := range ids {
+			if s, ok := fetched[id]; ok {
+				songs[i] = s
+				if p, ok := oldPos[id]; ok {
+					o := old[p]
+					o.Pos = s.Pos
+					if !reflect.DeepEqual(s, o) {
+						updated = append(updated, s)
+					}
+				}
+				continue
+			}
+			p, ok := oldPos[id]
+			if !ok {
+				return nil, errInconsistentQueue
+			}
+			songs[i] = old[p]
+			songs[i].Pos = i
+		}
+		return m.set(version, songs, updated), nil
+	}
+}
+
+// set replaces the queue with songs, and returns the change from the
+// previous queue.
+func (m *QueueMirror) set(version int, songs, updated []Song) *QueueChange {
+	m.mu.Lock()
+	old := m.songs
+	m.songs, m.version = songs, version
+	m.mu.Unlock()
+	qc := diffQueue(old, songs)
+	qc.Version = version
+	qc.Updated = updated
+	return qc
+}
+
+// diffQueue returns the songs inserted, removed and moved between the
+// queues old and cur. Songs shifted by insertions and removals are not
+// reported as moved: a song is moved if its order relative to the other
+// songs changed.
+func diffQueue(old, cur []Song) *QueueChange {
+	qc := new(QueueChange)
+	oldPos := make(map[int]int, len(old))
+	for i := range old {
+		oldPos[old[i].ID] = i
+	}
+	curPos := make(map[int]int, len(cur))
+	for i := range cur {
+		curPos[cur[i].ID] = i
+	}
+	for _, s := range old {
+		if _, ok := curPos[s.ID]; !ok {
+			qc.Removed = append(qc.Removed, s)
+		}
+	}
+	var kept []int // old positions of the songs kept, in the new order
+	for _, s := range cur {
+		if p, ok := oldPos[s.ID]; ok {
+			kept = append(kept, p)
+		} else {
+			qc.Inserted = append(qc.Inserted, s)
+		}
+	}
+	// The songs in the longest increasing subsequence of old positions
+	// stay in place; the others moved.
+	stay := longestIncreasing(kept)
+	for _, p := range kept {
+		if !stay[p] {
+			id := old[p].ID
+			qc.Moved = append(qc.Moved, QueueMove{ID: id, From: p, To: curPos[id]})
+		}
+	}
+	return qc
+}
+
+// longestIncreasing returns the elements of a longest increasing
+// subsequence of v, whose elements are distinct.
+func longestIncreasing(v []int) map[int]bool {
+	var tails []int // index in v of the last element of subsequences
+	prev := make([]int, len(v))
+	for i, x := range v {
+		j := sort.Search(len(tails), func(j int) bool { return v[tails[j]] >= x })
+		prev[i] = -1
+		if j > 0 {
+			prev[i] = tails[j-1]
+		}
+		if j == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[j] = i
+		}
+	}
+	seq := make(map[int]bool, len(tails))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			seq[v[i]] = true
+		}
+	}
+	return seq
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func nextQueueChange(t *testing.T, m *QueueMirror) QueueChange {
+	t.Helper()
+	select {
+	case qc := <-m.Change:
+		return qc
+	case err := <-m.Error:
+		t.Fatalf("QueueMirror failed: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no queue change received")
+	}
+	panic("unreachable")
+}
+
+func checkSnapshot(t *testing.T, cli *Client, m *QueueMirror) {
+	t.Helper()
+	queue, err := cli.PlaylistSongs(-1, -1)
+	if err != nil {
+		t.Fatalf("Client.PlaylistSongs failed: %s", err)
+	}
+	if got := m.Snapshot(); !reflect.DeepEqual(got, queue) {
+		t.Fatalf("QueueMirror.Snapshot returned %d songs, different from the queue of %d songs", len(got), len(queue))
+	}
+	if v := queueVersion(t, cli); m.Version() != v {
+		t.Errorf("QueueMirror.Version is %d; want %d", m.Version(), v)
+	}
+}
+
+func TestQueueMirror(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	fillQueue(t, cli, Eq(TagAlbum, "Album 4"))
+	network, addr := localAddr()
+	m, err := NewQueueMirror(network, addr, "")
+	if err != nil {
+		t.Fatalf("NewQueueMirror failed: %s", err)
+	}
+	defer m.Close()
+	checkSnapshot(t, cli, m)
+	queue := m.Snapshot()
+
+	// Removing a song shifts the following songs, which are not moved.
+	if err := cli.Delete(2, -1); err != nil {
+		t.Fatalf("Client.Delete failed: %s", err)
+	}
+	qc := nextQueueChange(t, m)
+	if len(qc.Removed) != 1 || qc.Removed[0].ID != queue[2].ID || len(qc.Inserted) != 0 || len(qc.Moved) != 0 {
+		t.Errorf("Delete changed the queue with %+v; want song %d removed", qc, queue[2].ID)
+	}
+	checkSnapshot(t, cli, m)
+
+	id, err := cli.AddID("song0001.ogg", 1)
+	if err != nil {
+		t.Fatalf("Client.AddID failed: %s", err)
+	}
+	qc = nextQueueChange(t, m)
+	if len(qc.Inserted) != 1 || qc.Inserted[0].ID != id || qc.Inserted[0].Pos != 1 || len(qc.Removed) != 0 || len(qc.Moved) != 0 {
+		t.Errorf("AddID changed the queue with %+v; want song %d inserted at 1", qc, id)
+	}
+	checkSnapshot(t, cli, m)
+
+	last := queue[len(queue)-1].ID
+	if err := cli.MoveID(last, 0); err != nil {
+		t.Fatalf("Client.MoveID failed: %s", err)
+	}
+	qc = nextQueueChange(t, m)
+	want := []QueueMove{{ID: last, From: 9, To: 0}}
+	if !reflect.DeepEqual(qc.Moved, want) || len(qc.Inserted) != 0 || len(qc.Removed) != 0 || len(qc.Updated) != 0 {
+		t.Errorf("MoveID changed the queue with %+v; want moves %+v", qc, want)
+	}
+	checkSnapshot(t, cli, m)
+
+	// A song that moved and changed is fetched again.
+	cl := cli.BeginCommandList()
+	cl.MoveID(last, 5)
+	cl.SetPriorityID(7, last)
+	if err := cl.End(); err != nil {
+		t.Fatalf("CommandList.End failed: %s", err)
+	}
+	qc = nextQueueChange(t, m)
+	for len(qc.Updated) == 0 {
+		// The move and the priority change may be reported separately.
+		qc = nextQueueChange(t, m)
+	}
+	if len(qc.Updated) != 1 || qc.Updated[0].ID != last || qc.Updated[0].Prio != 7 {
+		t.Errorf("changing the priority of a moved song changed the queue with %+v; want song %d updated", qc, last)
+	}
+	checkSnapshot(t, cli, m)
+
+	if err := cli.Clear(); err != nil {
+		t.Fatalf("Client.Clear failed: %s", err)
+	}
+	qc = nextQueueChange(t, m)
+	if len(qc.Removed) != 10 || len(m.Snapshot()) != 0 {
+		t.Errorf("Clear changed the queue with %+v; want 10 songs removed", qc)
+	}
+}
+
+func TestQueueMirrorClose(t *testing.T) {
+	network, addr := localServer(t)
+	m, err := NewQueueMirror(network, addr, "")
+	if err != nil {
+		t.Fatalf("NewQueueMirror failed: %s", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("QueueMirror.Close failed: %s", err)
+	}
+	if _, ok := <-m.Change; ok {
+		t.Errorf("Change channel is not closed")
+	}
+}
+
+func TestDiffQueue(t *testing.T) {
+	songs := func(ids ...int) []Song {
+		var s []Song
+		for i, id := range ids {
+			s = append(s, Song{ID: id, Pos: i})
+		}
+		return s
+	}
+	qc := diffQueue(songs(1, 2, 3, 4, 5), songs(6, 4, 1, 2, 5, 7))
+	if len(qc.Inserted) != 2 || qc.Inserted[0].ID != 6 || qc.Inserted[1].ID != 7 {
+		t.Errorf("inserted %+v; want songs 6 and 7", qc.Inserted)
+	}
+	if len(qc.Removed) != 1 || qc.Removed[0].ID != 3 {
+		t.Errorf("removed %+v; want song 3", qc.Removed)
+	}
+	if want := []QueueMove{{ID: 4, From: 3, To: 1}}; !reflect.DeepEqual(qc.Moved, want) {
+		t.Errorf("moved %+v; want %+v", qc.Moved, want)
+	}
+}
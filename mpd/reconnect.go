@@ -0,0 +1,175 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// WithReconnect makes the client transparently reconnect to MPD when the
+// connection is lost, e.g. because MPD closed it after its
+// connection_timeout. The next request redials, making up to attempts
+// attempts and waiting between them for backoff, doubled after each
+// failure.
+//
+// After reconnecting, the client authenticates again with the password
+// given with WithPassword, and restores the partition selected by
+// Partition and the tag types set by the TagTypes methods. Requests that are
+// safe to repeat (e.g. Status or Find) are retried once if the connection
+// is lost while they are in progress; other requests (e.g. AddID) fail with
+// the error that broke the connection.
+//
+// A password sent with a password command, e.g. by DialAuthenticated or
+// Command("password %s", pw), is not sent again after reconnecting. Use
+// WithPassword to authenticate a client that reconnects.
+func WithReconnect(attempts int, backoff time.Duration) DialOption {
+	return func(o *dialOptions) {
+		o.reconnectAttempts = attempts
+		o.reconnectBackoff = backoff
+	}
+}
+
+// isConnError reports whether err means that the connection to MPD is
+// broken.
+func isConnError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// A request interrupted by its context closes the connection.
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.ErrClosedPipe) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// reconnect replaces the broken connection by a new one. It must be called
+// with c.sem held.
+func (c *Client) reconnect(ctx context.Context) error {
+	backoff := c.dialOptions.reconnectBackoff
+	var err error
+	for i := 0; i < c.dialOptions.reconnectAttempts; i++ {
+		if i > 0 {
+			t := time.NewTimer(backoff)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+		var nc *Client
+		if nc, err = c.redial(ctx); err == nil {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.closed {
+				nc.Close()
+				return ErrClosed
+			}
+			c.text, c.netConn, c.version = nc.text, nc.netConn, nc.version
+			c.selected = c.partition
+			return nil
+		}
+	}
+	return err
+}
+
+// redial connects to MPD again and restores the state of the connection.
+func (c *Client) redial(ctx context.Context) (*Client, error) {
+	nc, err := dialContext(ctx, c.network, c.addr, c.dialOptions)
+	if err != nil {
+		return nil, err
+	}
+	restore := nc.WithContext(ctx)
+	if c.partition != "" {
+		err = restore.Command("partition %s", c.partition).OK()
+	}
+	for _, cmd := range c.tagTypes {
+		if err != nil {
+			break
+		}
+		err = restore.Command("%s", Quoted(cmd)).OK()
+	}
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return nc, nil
+}
+
+// idempotentCommands are the commands that can be sent again without
+// changing their outcome.
+var idempotentCommands = map[string]bool{
+	"albumart":           true,
+	"channels":           true,
+	"clearerror":         true,
+	"commands":           true,
+	"consume":            true,
+	"count":              true,
+	"crossfade":          true,
+	"currentsong":        true,
+	"decoders":           true,
+	"disableoutput":      true,
+	"enableoutput":       true,
+	"find":               true,
+	"getvol":             true,
+	"list":               true,
+	"listall":            true,
+	"listallinfo":        true,
+	"listmounts":         true,
+	"listneighbors":      true,
+	"listpartitions":     true,
+	"listplaylist":       true,
+	"listplaylistinfo":   true,
+	"listplaylists":      true,
+	"lsinfo":             true,
+	"mixrampdb":          true,
+	"mixrampdelay":       true,
+	"notcommands":        true,
+	"outputs":            true,
+	"outputset":          true,
+	"partition":          true,
+	"password":           true,
+	"ping":               true,
+	"playlistfind":       true,
+	"playlistid":         true,
+	"playlistinfo":       true,
+	"playlistsearch":     true,
+	"plchanges":          true,
+	"plchangesposid":     true,
+	"random":             true,
+	"readcomments":       true,
+	"readpicture":        true,
+	"repeat":             true,
+	"replay_gain_mode":   true,
+	"replay_gain_status": true,
+	"search":             true,
+	"searchcount":        true,
+	"setvol":             true,
+	"single":             true,
+	"stats":              true,
+	"status":             true,
+	"tagtypes":           true,
+	"urlhandlers":        true,
+}
+
+// idempotent reports whether the command line cmd can be sent again if
+// its response was lost.
+func idempotent(cmd string) bool {
+	name := cmd
+	if i := strings.IndexByte(cmd, ' '); i >= 0 {
+		name = cmd[:i]
+	}
+	if name == "sticker" {
+		return !strings.HasPrefix(cmd, "sticker delete ")
+	}
+	return idempotentCommands[name]
+}
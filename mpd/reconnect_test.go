@@ -0,0 +1,130 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// dropConn makes the server close the connection, as MPD does after
+// connection_timeout.
+func dropConn(t *testing.T, cli *Client) {
+	t.Helper()
+	if err := cli.printfLine("close"); err != nil {
+		t.Fatalf("sending close failed: %s", err)
+	}
+}
+
+func TestConnectionLost(t *testing.T) {
+	cli := localDial(t)
+	defer cli.Close()
+
+	dropConn(t, cli)
+	if _, err := cli.Status(); err == nil {
+		t.Fatalf("Client.Status succeeded on lost connection")
+	}
+	if err := cli.Ping(); err != ErrClosed {
+		t.Fatalf("Client.Ping after lost connection = %v; want %v", err, ErrClosed)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	cli := localDial(t, WithReconnect(3, 10*time.Millisecond))
+	defer teardown(cli, t)
+
+	cli.NewPartition("reconnect") // may already exist
+	if err := cli.Partition("reconnect"); err != nil {
+		t.Fatalf("Client.Partition failed: %s", err)
+	}
+	if err := cli.TagTypesClear(); err != nil {
+		t.Fatalf("Client.TagTypesClear failed: %s", err)
+	}
+	if err := cli.TagTypesEnable("Artist", "Title"); err != nil {
+		t.Fatalf("Client.TagTypesEnable failed: %s", err)
+	}
+
+	dropConn(t, cli)
+	status, err := cli.Status()
+	if err != nil {
+		t.Fatalf("Client.Status after lost connection failed: %s", err)
+	}
+	if status["partition"] != "reconnect" {
+		t.Errorf("partition after reconnect is %q; want %q", status["partition"], "reconnect")
+	}
+	tags, err := cli.TagTypes()
+	if err != nil {
+		t.Fatalf("Client.TagTypes failed: %s", err)
+	}
+	if want := []string{"Artist", "Title"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tag types after reconnect are %v; want %v", tags, want)
+	}
+
+	// Non-idempotent commands are not retried.
+	dropConn(t, cli)
+	if _, err := cli.AddID("song0000.ogg", -1); !isConnError(err) {
+		t.Fatalf("Client.AddID on lost connection = %v; want connection error", err)
+	}
+	if err := cli.Ping(); err != nil {
+		t.Fatalf("Client.Ping after lost connection failed: %s", err)
+	}
+
+	// The connection is not reestablished after Close.
+	if err := cli.Close(); err != nil {
+		t.Fatalf("Client.Close failed: %s", err)
+	}
+	if err := cli.Ping(); err != ErrClosed {
+		t.Fatalf("Client.Ping after Close = %v; want %v", err, ErrClosed)
+	}
+}
+
+func TestVersionDuringReconnect(t *testing.T) {
+	cli := localDial(t, WithReconnect(3, 10*time.Millisecond))
+	defer teardown(cli, t)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if cli.Version() == "" {
+				t.Errorf("Client.Version returned an empty version")
+				return
+			}
+		}
+	}()
+	dropConn(t, cli)
+	if _, err := cli.Status(); err != nil {
+		t.Errorf("Client.Status after lost connection failed: %s", err)
+	}
+	<-done
+}
+
+func TestReconnectOnce(t *testing.T) {
+	dials := 0
+	dial := pipeDial("OK MPD 0.23.0")
+	cli, err := DialWithOptions("pipe", "", WithReconnect(3, time.Millisecond), WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials++
+		if dials > 1 {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+		}
+		return dial(ctx, network, addr)
+	}))
+	if err != nil {
+		t.Fatalf("DialWithOptions = %v, %s want PTR, nil", cli, err)
+	}
+	defer cli.Close()
+
+	cli.netConn.Close()
+	if err := cli.Ping(); !IsConnectionError(err) {
+		t.Fatalf("Client.Ping after lost connection = %v; want a connection error", err)
+	}
+	if dials != 1+3 {
+		t.Errorf("Client redialed %d times; want 3", dials-1)
+	}
+}
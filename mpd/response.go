@@ -40,60 +40,72 @@ func (cmd *Command) String() string {
 	return cmd.cmd
 }
 
+// exec sends command to server and reads the response with read.
+func (cmd *Command) exec(read func() error) error {
+	c := cmd.client
+	err := c.run(idempotent(cmd.cmd), func() error {
+		id, err := c.cmd("%v", cmd.cmd)
+		if err != nil {
+			return err
+		}
+		c.text.StartResponse(id)
+		defer c.text.EndResponse(id)
+		return read()
+	})
+	return withCommand(err, cmd.cmd)
+}
+
 // OK sends command to server and checks for error.
 func (cmd *Command) OK() error {
-	id, err := cmd.client.cmd("%v", cmd.cmd)
-	if err != nil {
-		return err
-	}
-	cmd.client.text.StartResponse(id)
-	defer cmd.client.text.EndResponse(id)
-	return cmd.client.readOKLine("OK")
+	return cmd.exec(func() error {
+		return cmd.client.readOKLine("OK")
+	})
 }
 
 // Attrs sends command to server and reads attributes returned in response.
-func (cmd *Command) Attrs() (Attrs, error) {
-	id, err := cmd.client.cmd(cmd.cmd)
-	if err != nil {
-		return nil, err
-	}
-	cmd.client.text.StartResponse(id)
-	defer cmd.client.text.EndResponse(id)
-	return cmd.client.readAttrs("OK")
+func (cmd *Command) Attrs() (attrs Attrs, err error) {
+	err = cmd.exec(func() error {
+		attrs, err = cmd.client.readAttrs("OK")
+		return err
+	})
+	return
 }
 
 // AttrsList sends command to server and reads a list of attributes returned in response.
 // Each attribute group starts with key startKey.
-func (cmd *Command) AttrsList(startKey string) ([]Attrs, error) {
-	id, err := cmd.client.cmd(cmd.cmd)
-	if err != nil {
-		return nil, err
-	}
-	cmd.client.text.StartResponse(id)
-	defer cmd.client.text.EndResponse(id)
-	return cmd.client.readAttrsList(startKey)
+func (cmd *Command) AttrsList(startKey string) (attrs []Attrs, err error) {
+	err = cmd.exec(func() error {
+		attrs, err = cmd.client.readAttrsList(startKey, "OK")
+		return err
+	})
+	return
 }
 
 // Strings sends command to server and reads a list of strings returned in response.
 // Each string have the key key.
-func (cmd *Command) Strings(key string) ([]string, error) {
-	id, err := cmd.client.cmd(cmd.cmd)
-	if err != nil {
-		return nil, err
-	}
-	cmd.client.text.StartResponse(id)
-	defer cmd.client.text.EndResponse(id)
-	return cmd.client.readList(key)
+func (cmd *Command) Strings(key string) (list []string, err error) {
+	err = cmd.exec(func() error {
+		list, err = cmd.client.readList(key, "OK")
+		return err
+	})
+	return
 }
 
 // Binary sends command to server and reads its binary response, returning the data and its total size (which can be
 // greater than the returned chunk).
-func (cmd *Command) Binary() ([]byte, int, error) {
-	id, err := cmd.client.cmd(cmd.cmd)
-	if err != nil {
-		return nil, 0, err
-	}
-	cmd.client.text.StartResponse(id)
-	defer cmd.client.text.EndResponse(id)
-	return cmd.client.readBinary()
+func (cmd *Command) Binary() (data []byte, size int, err error) {
+	err = cmd.exec(func() error {
+		data, size, err = cmd.client.readBinary("OK")
+		return err
+	})
+	return
+}
+
+// Songs sends command to server and reads a list of songs returned in response.
+func (cmd *Command) Songs() (songs []Song, err error) {
+	err = cmd.exec(func() error {
+		songs, err = cmd.client.readSongs("OK")
+		return err
+	})
+	return
 }
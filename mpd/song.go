@@ -0,0 +1,113 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"strconv"
+	"time"
+)
+
+// Song is a song returned by MPD, e.g. a song in the database or in the
+// queue.
+type Song struct {
+	URI string
+
+	// Tags maps tag names (e.g. "Artist") to their values. A tag may
+	// have several values, e.g. a song with several artists.
+	Tags map[string][]string
+
+	Duration     time.Duration
+	Pos          int // position in the queue, or -1
+	ID           int // queue id, or -1
+	Prio         int // priority in the queue
+	Format       string
+	LastModified time.Time
+}
+
+func newSong(uri string) *Song {
+	return &Song{
+		URI:  uri,
+		Tags: make(map[string][]string),
+		Pos:  -1,
+		ID:   -1,
+	}
+}
+
+// Tag returns the first value of the tag name, or the empty string if the
+// song doesn't have this tag.
+func (s *Song) Tag(name string) string {
+	if v := s.Tags[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// set sets the attribute key returned by MPD to value. Keys that are not
+// known song attributes are treated as tags.
+func (s *Song) set(key, value string) (err error) {
+	switch key {
+	case "file":
+		s.URI = value
+	case "duration":
+		s.Duration, err = parseSeconds(value)
+	case "Time":
+		// Deprecated integer version of duration.
+		if s.Duration == 0 {
+			s.Duration, err = parseSeconds(value)
+		}
+	case "Pos":
+		s.Pos, err = strconv.Atoi(value)
+	case "Id":
+		s.ID, err = strconv.Atoi(value)
+	case "Prio":
+		s.Prio, err = strconv.Atoi(value)
+	case "Format":
+		s.Format = value
+	case "Last-Modified":
+		s.LastModified, err = time.Parse(time.RFC3339, value)
+	case "Added", "Range":
+		// Not supported.
+	default:
+		s.Tags[key] = append(s.Tags[key], value)
+	}
+	if err != nil {
+		return protocolError("can't parse "+key, key+": "+value)
+	}
+	return nil
+}
+
+// CurrentSongInfo returns the current song in the playlist, or nil if there
+// is none.
+func (c *Client) CurrentSongInfo() (*Song, error) {
+	songs, err := c.Command("currentsong").Songs()
+	if err != nil || len(songs) == 0 {
+		return nil, err
+	}
+	return &songs[0], nil
+}
+
+// PlaylistSongs is like PlaylistInfo, but returns songs.
+func (c *Client) PlaylistSongs(start, end int) ([]Song, error) {
+	cmd, err := c.playlistInfoCommand(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Songs()
+}
+
+// FindSongs is like Find, but returns songs.
+func (c *Client) FindSongs(args ...string) ([]Song, error) {
+	return c.Command("find %s", Quoted(quoteArgs(args))).Songs()
+}
+
+// SearchSongs is like Search, but returns songs.
+func (c *Client) SearchSongs(args ...string) ([]Song, error) {
+	return c.Command("search %s", Quoted(quoteArgs(args))).Songs()
+}
+
+// ListAllSongs is like ListAllInfo, but returns songs.
+func (c *Client) ListAllSongs(uri string) ([]Song, error) {
+	return c.Command("listallinfo %s", uri).Songs()
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListAllSongs(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	songs, err := cli.ListAllSongs("/")
+	if err != nil {
+		t.Fatalf("Client.ListAllSongs failed: %s", err)
+	}
+	if len(songs) != 100 {
+		t.Fatalf("Client.ListAllSongs returned %d songs; want 100", len(songs))
+	}
+	want := Song{
+		URI: "song0000.ogg",
+		Tags: map[string][]string{
+			"Artist": {"Artist 0", "Guest"},
+			"Album":  {"Album 0"},
+			"Title":  {"Title 0"},
+			"Track":  {"1"},
+			"Genre":  {"Rock", "Pop"},
+		},
+		Duration:     180500 * time.Millisecond,
+		Pos:          -1,
+		ID:           -1,
+		Format:       "44100:16:2",
+		LastModified: time.Date(2014, 7, 2, 12, 32, 26, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(songs[0], want) {
+		t.Errorf("first song is %+v; want %+v", songs[0], want)
+	}
+	if got := songs[0].Tag("Artist"); got != "Artist 0" {
+		t.Errorf("Song.Tag(%q) = %q; want %q", "Artist", got, "Artist 0")
+	}
+	if got := songs[0].Tag("Composer"); got != "" {
+		t.Errorf("Song.Tag(%q) = %q; want empty string", "Composer", got)
+	}
+}
+
+func TestFindSongs(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	songs, err := cli.FindSongs("artist", "Guest")
+	if err != nil {
+		t.Fatalf("Client.FindSongs failed: %s", err)
+	}
+	if len(songs) != 10 {
+		t.Fatalf("Client.FindSongs returned %d songs; want 10", len(songs))
+	}
+	for _, song := range songs {
+		if artists := song.Tags["Artist"]; len(artists) != 2 {
+			t.Errorf("song %s has artists %q; want 2 artists", song.URI, artists)
+		}
+	}
+
+	songs, err = cli.SearchSongs("title", "title 1")
+	if err != nil {
+		t.Fatalf("Client.SearchSongs failed: %s", err)
+	}
+	if len(songs) != 11 {
+		t.Errorf("Client.SearchSongs returned %d songs; want 11", len(songs))
+	}
+}
+
+func TestPlaylistSongs(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+	if !loadTestFiles(t, cli, 3) {
+		return
+	}
+
+	songs, err := cli.PlaylistSongs(-1, -1)
+	if err != nil {
+		t.Fatalf("Client.PlaylistSongs failed: %s", err)
+	}
+	if len(songs) != 3 {
+		t.Fatalf("Client.PlaylistSongs returned %d songs; want 3", len(songs))
+	}
+	for i, song := range songs {
+		if song.Pos != i {
+			t.Errorf("song %d has position %d", i, song.Pos)
+		}
+		if song.ID < 0 {
+			t.Errorf("song %d has no id", i)
+		}
+	}
+	songs, err = cli.PlaylistSongs(1, 3)
+	if err != nil {
+		t.Fatalf("Client.PlaylistSongs(1, 3) failed: %s", err)
+	}
+	if len(songs) != 2 || songs[0].Pos != 1 {
+		t.Errorf("Client.PlaylistSongs(1, 3) returned %+v; want songs at positions 1 and 2", songs)
+	}
+
+	if err := cli.Play(0); err != nil {
+		t.Fatalf("Client.Play failed: %s", err)
+	}
+	song, err := cli.CurrentSongInfo()
+	if err != nil {
+		t.Fatalf("Client.CurrentSongInfo failed: %s", err)
+	}
+	if song == nil || song.URI == "" {
+		t.Errorf("Client.CurrentSongInfo returned %+v; want a song", song)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// State is the playback state of MPD.
+type State int
+
+// Playback states. StateUnknown is used when MPD doesn't report the state,
+// or reports one that isn't known to this package.
+const (
+	StateUnknown State = iota
+	StateStop
+	StatePlay
+	StatePause
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStop:
+		return "stop"
+	case StatePlay:
+		return "play"
+	case StatePause:
+		return "pause"
+	}
+	return "unknown"
+}
+
+// StatusInfo is the status of MPD, as returned by Client.StatusInfo.
+//
+// Fields that MPD doesn't report are left to their zero value, except for
+// Volume, Song, SongID, NextSong and NextSongID, which are -1 when missing
+// (e.g. when there is no mixer or no current song). Fields unknown to this
+// package are only available in Attrs.
+type StatusInfo struct {
+	Partition      string
+	Volume         int // 0-100
+	Repeat         bool
+	Random         bool
+	Single         bool // also true in oneshot mode
+	Consume        bool // also true in oneshot mode
+	SingleMode     SingleMode
+	ConsumeMode    ConsumeMode
+	Playlist       int // playlist version number
+	PlaylistLength int
+	State          State
+	Song           int // playlist position of the current song
+	SongID         int
+	NextSong       int
+	NextSongID     int
+	Elapsed        time.Duration
+	Duration       time.Duration
+	Bitrate        int    // kbit/s
+	Audio          string // sampleRate:bits:channels
+	Crossfade      time.Duration
+	MixRampDB      float64
+	MixRampDelay   time.Duration
+	UpdatingDB     int // update job id
+	Error          string
+
+	// Attrs holds all the attributes returned by MPD.
+	Attrs Attrs
+}
+
+// ParseStatus converts the attributes returned by Client.Status into a
+// StatusInfo. It can be used with the attributes promised by
+// CommandList.Status. An error is returned if an attribute has a value
+// that can't be parsed.
+func ParseStatus(a Attrs) (*StatusInfo, error) {
+	s := &StatusInfo{
+		Volume:     -1,
+		Song:       -1,
+		SongID:     -1,
+		NextSong:   -1,
+		NextSongID: -1,
+		Attrs:      a,
+	}
+	var err error
+	for key, value := range a {
+		switch key {
+		case "partition":
+			s.Partition = value
+		case "volume":
+			s.Volume, err = strconv.Atoi(value)
+		case "repeat":
+			s.Repeat, err = parseBool(value)
+		case "random":
+			s.Random, err = parseBool(value)
+		case "single":
+			var m int
+			m, err = parseOneshotMode(value)
+			s.Single, s.SingleMode = m != 0, SingleMode(m)
+		case "consume":
+			var m int
+			m, err = parseOneshotMode(value)
+			s.Consume, s.ConsumeMode = m != 0, ConsumeMode(m)
+		case "playlist":
+			s.Playlist, err = strconv.Atoi(value)
+		case "playlistlength":
+			s.PlaylistLength, err = strconv.Atoi(value)
+		case "state":
+			s.State = parseState(value)
+		case "song":
+			s.Song, err = strconv.Atoi(value)
+		case "songid":
+			s.SongID, err = strconv.Atoi(value)
+		case "nextsong":
+			s.NextSong, err = strconv.Atoi(value)
+		case "nextsongid":
+			s.NextSongID, err = strconv.Atoi(value)
+		case "elapsed":
+			s.Elapsed, err = parseSeconds(value)
+		case "duration":
+			s.Duration, err = parseSeconds(value)
+		case "bitrate":
+			s.Bitrate, err = strconv.Atoi(value)
+		case "audio":
+			s.Audio = value
+		case "xfade":
+			s.Crossfade, err = parseSeconds(value)
+		case "mixrampdb":
+			s.MixRampDB, err = strconv.ParseFloat(value, 64)
+		case "mixrampdelay":
+			s.MixRampDelay, err = parseSeconds(value)
+		case "updating_db":
+			s.UpdatingDB, err = strconv.Atoi(value)
+		case "error":
+			s.Error = value
+		}
+		if err != nil {
+			return nil, protocolError("can't parse "+key, key+": "+value)
+		}
+	}
+	return s, nil
+}
+
+// StatusInfo returns the current status of MPD.
+func (c *Client) StatusInfo() (*StatusInfo, error) {
+	attrs, err := c.Status()
+	if err != nil {
+		return nil, err
+	}
+	return ParseStatus(attrs)
+}
+
+func parseState(s string) State {
+	switch s {
+	case "stop":
+		return StateStop
+	case "play":
+		return StatePlay
+	case "pause":
+		return StatePause
+	}
+	return StateUnknown
+}
+
+func parseBool(s string) (bool, error) {
+	switch s {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// parseSeconds parses a (possibly fractional) number of seconds. NaN, used
+// by MPD for disabled settings, is parsed as zero.
+func parseSeconds(s string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) {
+		return 0, nil
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
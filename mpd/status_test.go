@@ -0,0 +1,135 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseStatus(t *testing.T) {
+	attrs := Attrs{
+		"partition":      "default",
+		"volume":         "42",
+		"repeat":         "1",
+		"random":         "0",
+		"single":         "oneshot",
+		"consume":        "0",
+		"playlist":       "7",
+		"playlistlength": "12",
+		"state":          "play",
+		"song":           "3",
+		"songid":         "4",
+		"nextsong":       "4",
+		"nextsongid":     "5",
+		"elapsed":        "12.5",
+		"duration":       "200.250",
+		"bitrate":        "320",
+		"audio":          "44100:24:2",
+		"xfade":          "5",
+		"mixrampdb":      "-17.5",
+		"mixrampdelay":   "nan",
+		"updating_db":    "3",
+		"error":          "oops",
+		"newfield":       "whatever",
+	}
+	want := &StatusInfo{
+		Partition:      "default",
+		Volume:         42,
+		Repeat:         true,
+		Single:         true,
+		SingleMode:     SingleOneshot,
+		Playlist:       7,
+		PlaylistLength: 12,
+		State:          StatePlay,
+		Song:           3,
+		SongID:         4,
+		NextSong:       4,
+		NextSongID:     5,
+		Elapsed:        12500 * time.Millisecond,
+		Duration:       200250 * time.Millisecond,
+		Bitrate:        320,
+		Audio:          "44100:24:2",
+		Crossfade:      5 * time.Second,
+		MixRampDB:      -17.5,
+		UpdatingDB:     3,
+		Error:          "oops",
+		Attrs:          attrs,
+	}
+	got, err := ParseStatus(attrs)
+	if err != nil {
+		t.Fatalf("ParseStatus failed: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseStatus returned %+v; want %+v", got, want)
+	}
+}
+
+func TestParseStatusMissing(t *testing.T) {
+	got, err := ParseStatus(Attrs{"state": "bogus"})
+	if err != nil {
+		t.Fatalf("ParseStatus failed: %s", err)
+	}
+	if got.State != StateUnknown {
+		t.Errorf("State is %v; want %v", got.State, StateUnknown)
+	}
+	if got.Volume != -1 || got.Song != -1 || got.SongID != -1 || got.NextSong != -1 || got.NextSongID != -1 {
+		t.Errorf("missing positions/ids are not -1: %+v", got)
+	}
+}
+
+func TestParseStatusInvalid(t *testing.T) {
+	for _, attrs := range []Attrs{
+		{"volume": "loud"},
+		{"repeat": "yes"},
+		{"elapsed": "1:20"},
+	} {
+		var pe *ProtocolError
+		if _, err := ParseStatus(attrs); !errors.As(err, &pe) {
+			t.Errorf("ParseStatus(%v) returned %v; want a ProtocolError", attrs, err)
+		}
+	}
+}
+
+func TestStatusInfo(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	if err := cli.Repeat(true); err != nil {
+		t.Fatalf("Client.Repeat failed: %s", err)
+	}
+	defer cli.Repeat(false)
+	if err := cli.Stop(); err != nil {
+		t.Fatalf("Client.Stop failed: %s", err)
+	}
+	status, err := cli.StatusInfo()
+	if err != nil {
+		t.Fatalf("Client.StatusInfo failed: %s", err)
+	}
+	if status.State != StateStop {
+		t.Errorf("State is %v; want %v", status.State, StateStop)
+	}
+	if !status.Repeat {
+		t.Errorf("Repeat is false; want true")
+	}
+	if status.Attrs["state"] != "stop" {
+		t.Errorf("Attrs has state %q; want %q", status.Attrs["state"], "stop")
+	}
+
+	cl := cli.BeginCommandList()
+	pa := cl.Status()
+	if err := cl.End(); err != nil {
+		t.Fatalf("CommandList.End failed: %s", err)
+	}
+	attrs, err := pa.Value()
+	if err != nil {
+		t.Fatalf("PromisedAttrs.Value failed: %s", err)
+	}
+	if status, err := ParseStatus(attrs); err != nil || status.State != StateStop {
+		t.Errorf("ParseStatus of promised status = %+v, %v; want state %v", status, err, StateStop)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+// Mount is a storage mounted in the music directory of MPD.
+type Mount struct {
+	Path    string // path in the music directory, empty for the root
+	Storage string // URI of the storage, e.g. "nfs://server/music"
+}
+
+// Neighbor is a storage found on the local network, which can be mounted.
+type Neighbor struct {
+	URI  string // URI of the storage, e.g. "smb://server"
+	Name string // human readable name of the storage
+}
+
+// Mount mounts the storage uri at path in the music directory. The
+// database is then updated with the songs found in the storage.
+func (c *Client) Mount(path, uri string) error {
+	return c.Command("mount %s %s", path, uri).OK()
+}
+
+// Unmount unmounts the storage mounted at path.
+func (c *Client) Unmount(path string) error {
+	return c.Command("unmount %s", path).OK()
+}
+
+// ListMounts returns the storages mounted in the music directory,
+// including the music directory itself, mounted at the empty path.
+func (c *Client) ListMounts() ([]Mount, error) {
+	attrs, err := c.Command("listmounts").AttrsList("mount")
+	if err != nil {
+		return nil, err
+	}
+	mounts := make([]Mount, len(attrs))
+	for i, a := range attrs {
+		mounts[i] = Mount{Path: a["mount"], Storage: a["storage"]}
+	}
+	return mounts, nil
+}
+
+// ListNeighbors returns the storages found on the local network by the
+// neighbor plugins of MPD.
+func (c *Client) ListNeighbors() ([]Neighbor, error) {
+	attrs, err := c.Command("listneighbors").AttrsList("neighbor")
+	if err != nil {
+		return nil, err
+	}
+	neighbors := make([]Neighbor, len(attrs))
+	for i, a := range attrs {
+		neighbors[i] = Neighbor{URI: a["neighbor"], Name: a["name"]}
+	}
+	return neighbors, nil
+}
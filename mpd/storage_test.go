@@ -0,0 +1,66 @@
+// Copyright 2026 The GoMPD Authors. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mpd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMounts(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	root := Mount{Path: "", Storage: "/var/lib/mpd/music"}
+	mounts, err := cli.ListMounts()
+	if err != nil {
+		t.Fatalf("Client.ListMounts failed: %s", err)
+	}
+	if want := []Mount{root}; !reflect.DeepEqual(mounts, want) {
+		t.Errorf("Client.ListMounts returned %+v; want %+v", mounts, want)
+	}
+
+	nfs := Mount{Path: "nas", Storage: "nfs://nas.local/music"}
+	if err := cli.Mount(nfs.Path, nfs.Storage); err != nil {
+		t.Fatalf("Client.Mount failed: %s", err)
+	}
+	if err := cli.Mount(nfs.Path, "smb://other/music"); err == nil {
+		t.Errorf("Client.Mount succeeded on a mount point in use")
+	}
+	mounts, err = cli.ListMounts()
+	if err != nil {
+		t.Fatalf("Client.ListMounts failed: %s", err)
+	}
+	if want := []Mount{root, nfs}; !reflect.DeepEqual(mounts, want) {
+		t.Errorf("Client.ListMounts returned %+v; want %+v", mounts, want)
+	}
+
+	if err := cli.Unmount(nfs.Path); err != nil {
+		t.Fatalf("Client.Unmount failed: %s", err)
+	}
+	if err := cli.Unmount(nfs.Path); err == nil {
+		t.Errorf("Client.Unmount succeeded twice")
+	}
+	if err := cli.Unmount(""); err == nil {
+		t.Errorf("Client.Unmount succeeded on the music directory")
+	}
+}
+
+func TestListNeighbors(t *testing.T) {
+	cli := localDial(t)
+	defer teardown(cli, t)
+
+	neighbors, err := cli.ListNeighbors()
+	if err != nil {
+		t.Fatalf("Client.ListNeighbors failed: %s", err)
+	}
+	want := []Neighbor{
+		{URI: "smb://nas", Name: "NAS (Samba 4.15.13)"},
+		{URI: "upnp://uuid:4d696e69-444c-164e-9d41-b827eb54e3ea/0", Name: "MiniDLNA"},
+	}
+	if !reflect.DeepEqual(neighbors, want) {
+		t.Errorf("Client.ListNeighbors returned %+v; want %+v", neighbors, want)
+	}
+}
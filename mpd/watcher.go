@@ -12,6 +12,11 @@ type Watcher struct {
 	names chan []string // channel to set new subsystems to watch
 	Event chan string   // event channel
 	Error chan error    // error channel
+
+	// Message receives the messages sent to the subscribed channels, if
+	// the Watcher was created with NewMessageWatcher. It is nil
+	// otherwise.
+	Message chan Message
 }
 
 // NewWatcher connects to MPD server and watches for changes in subsystems
@@ -20,11 +25,31 @@ type Watcher struct {
 // See http://www.musicpd.org/doc/protocol/command_reference.html#command_idle
 // for valid subsystem names.
 func NewWatcher(net, addr, passwd string, names ...string) (w *Watcher, err error) {
-	conn, err := DialAuthenticated(net, addr, passwd)
+	conn, err := DialWithOptions(net, addr, WithPassword(passwd))
+	if err != nil {
+		return
+	}
+	w = newWatcher(conn)
+	go w.watch(names...)
+	return
+}
+
+// NewPartitionWatcher is like NewWatcher, but watches for changes in the
+// partition named partition. Changes in the subsystems that are specific
+// to a partition, such as "player", "mixer" or "playlist", are only
+// reported for this partition.
+func NewPartitionWatcher(net, addr, passwd, partition string, names ...string) (w *Watcher, err error) {
+	conn, err := DialWithOptions(net, addr, WithPassword(passwd), WithPartition(partition))
 	if err != nil {
 		return
 	}
-	w = &Watcher{
+	w = newWatcher(conn)
+	go w.watch(names...)
+	return
+}
+
+func newWatcher(conn *Client) *Watcher {
+	return &Watcher{
 		conn:  conn,
 		Event: make(chan string),
 		Error: make(chan error),
@@ -33,10 +58,43 @@ func NewWatcher(net, addr, passwd string, names ...string) (w *Watcher, err erro
 		names: make(chan []string, 1),
 		exit:  make(chan bool, 1),
 	}
-	go w.watch(names...)
+}
+
+// NewMessageWatcher is like NewWatcher, but the connection also subscribes
+// to channels. When the "message" subsystem changes, the messages are read
+// and sent on the Message channel instead of reporting the change on the
+// Event channel. The "message" subsystem is always watched.
+func NewMessageWatcher(net, addr, passwd string, channels []string, names ...string) (w *Watcher, err error) {
+	conn, err := DialWithOptions(net, addr, WithPassword(passwd))
+	if err != nil {
+		return
+	}
+	for _, channel := range channels {
+		if err = conn.Subscribe(channel); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	w = newWatcher(conn)
+	w.Message = make(chan Message)
+	go w.watch(w.withMessage(names)...)
 	return
 }
 
+// withMessage adds the "message" subsystem to names if messages are
+// watched.
+func (w *Watcher) withMessage(names []string) []string {
+	if w.Message == nil || len(names) == 0 {
+		return names
+	}
+	for _, name := range names {
+		if name == "message" {
+			return names
+		}
+	}
+	return append(names[:len(names):len(names)], "message")
+}
+
 func (w *Watcher) watch(names ...string) {
 	defer w.closeChans()
 
@@ -61,6 +119,12 @@ func (w *Watcher) watch(names ...string) {
 			w.Error <- err
 		default:
 			for _, name := range changed {
+				if name == "message" && w.Message != nil {
+					if !w.sendMessages() {
+						return
+					}
+					continue
+				}
 				w.Event <- name
 			}
 		}
@@ -78,9 +142,34 @@ func (w *Watcher) watch(names ...string) {
 	}
 }
 
+// sendMessages reads the messages received and sends them on the Message
+// channel. It returns false if the Watcher is being closed.
+func (w *Watcher) sendMessages() bool {
+	msgs, err := w.conn.ReadMessages()
+	if err != nil {
+		select {
+		case w.Error <- err:
+			return true
+		case <-w.exit:
+			return false
+		}
+	}
+	for _, m := range msgs {
+		select {
+		case w.Message <- m:
+		case <-w.exit:
+			return false
+		}
+	}
+	return true
+}
+
 func (w *Watcher) closeChans() {
 	close(w.Event)
 	close(w.Error)
+	if w.Message != nil {
+		close(w.Message)
+	}
 	close(w.names)
 	close(w.exit)
 	close(w.done)
@@ -88,25 +177,32 @@ func (w *Watcher) closeChans() {
 
 func (w *Watcher) consume() {
 	for {
+		var ok bool
 		select {
-		case <-w.Event:
-		case <-w.Error:
+		case _, ok = <-w.Event:
+		case _, ok = <-w.Error:
+		case _, ok = <-w.Message:
 		default:
 			return
 		}
+		if !ok {
+			// The watch goroutine has ended.
+			return
+		}
 	}
 }
 
 // Subsystems interrupts watching current subsystems, consumes all
-// outstanding values from Event and Error channels, and then
+// outstanding values from Event, Error and Message channels, and then
 // changes the subsystems to watch for to names.
 func (w *Watcher) Subsystems(names ...string) {
-	w.names <- names
+	w.names <- w.withMessage(names)
 	w.consume()
 	w.conn.noIdle()
 }
 
-// Close closes Event and Error channels, and the connection to MPD server.
+// Close closes Event, Error and Message channels, and the connection to MPD
+// server.
 func (w *Watcher) Close() error {
 	w.exit <- true
 	w.consume()
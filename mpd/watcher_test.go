@@ -10,7 +10,8 @@ import (
 )
 
 func localWatch(t *testing.T, names ...string) *Watcher {
-	net, addr := localAddr()
+	t.Helper()
+	net, addr := localServer(t)
 	w, err := NewWatcher(net, addr, "", names...)
 	if err != nil {
 		t.Fatalf("NewWatcher(%q) = %v, %s want PTR, nil", addr, w, err)
@@ -91,3 +92,25 @@ func TestWatcher(t *testing.T) {
 		t.Fatalf("Client.idle failed: %s\n", err)
 	}
 }
+
+func TestWatcherCloseAfterConnectionLost(t *testing.T) {
+	w := localWatch(t, "player")
+
+	// The pending idle fails and closes the connection while Close
+	// interrupts it.
+	go func() {
+		w.conn.mu.Lock()
+		w.conn.netConn.Close()
+		w.conn.mu.Unlock()
+	}()
+	done := make(chan struct{})
+	go func() {
+		w.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Watcher.Close hangs after the connection was lost")
+	}
+}